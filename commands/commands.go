@@ -17,16 +17,49 @@ var FlutterVersion = Command{
 	Args: []string{"--version"},
 }
 
+// / Get the Flutter version as JSON
+var FlutterVersionMachine = Command{
+	Name: "flutter",
+	Args: []string{"--version", "--machine"},
+}
+
+// / Print the Dart SDK version
+var DartVersion = Command{
+	Name: "dart",
+	Args: []string{"--version"},
+}
+
+// / List globally activated packages with their versions
+var DartPubGlobalList = Command{
+	Name: "dart",
+	Args: []string{"pub", "global", "list"},
+}
+
+// / Print the installed Patrol CLI version
+var PatrolVersion = Command{
+	Name: "patrol",
+	Args: []string{"--version"},
+}
+
 // / Get patrol verbose with extra information
 var PatrolDoctor = Command{
 	Name: "patrol",
 	Args: []string{"doctor", "--verbose"},
 }
 
+// / Name of the Patrol CLI package on pub
+const PatrolCLIPackage = "patrol_cli"
+
 // / This command is used to install the patrol_cli package globally using pub
 var PatrolInstall = Command{
 	Name: "dart",
-	Args: []string{"pub", "global", "activate", "patrol_cli"},
+	Args: []string{"pub", "global", "activate", PatrolCLIPackage},
+}
+
+// / Activates a package globally, the source and package are appended by the caller
+var PubGlobalActivate = Command{
+	Name: "dart",
+	Args: []string{"pub", "global", "activate"},
 }
 
 var CreatePatrolFolder = Command{
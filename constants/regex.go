@@ -5,9 +5,10 @@ import "regexp"
 // Returns a regex pattern that matches a version string
 // that starts with a custom prefix text, optionally followed by ":" or whitespace.
 // The pattern is case-insensitive and allows for optional whitespace around the version string.
+// Pre-release and build suffixes are captured too, e.g. "3.33.0-0.1.pre" on the Flutter beta channel.
 func Version(prefixText string) *regexp.Regexp {
 	escapedPrefix := regexp.QuoteMeta(prefixText) // Escape special characters in the prefix
-	return regexp.MustCompile(`(?i)` + escapedPrefix + `[\s:]*v?(\d+\.\d+\.\d+)`)
+	return regexp.MustCompile(`(?i)` + escapedPrefix + `[\s:]*v?(\d+\.\d+\.\d+(?:-[0-9A-Za-z.-]+)?(?:\+[0-9A-Za-z.-]+)?)`)
 }
 
 // AndroidApk returns a regex that matches app-*.apk and app-*-*.apk
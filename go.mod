@@ -4,6 +4,9 @@ go 1.24.2
 
 require github.com/Masterminds/semver/v3 v3.3.1 // direct
 
-require github.com/bitrise-io/go-steputils v1.0.6
+require (
+	github.com/bitrise-io/go-steputils v1.0.6
+	gopkg.in/yaml.v3 v3.0.1
+)
 
 require github.com/bitrise-io/go-utils v1.0.1 // indirect
@@ -1,26 +1,67 @@
 package main
 
 import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
 	build "patrol_install/steps/build"
+	build_report "patrol_install/steps/build/build_report"
+	build_constants "patrol_install/steps/build/constants"
+	"patrol_install/steps/build/executor"
+	"patrol_install/steps/build_cache"
+	"patrol_install/steps/build_hooks"
+	"patrol_install/steps/doctor"
+	doctor_report "patrol_install/steps/doctor/doctor_report"
 	"patrol_install/steps/export_artifacts"
+	export_artifacts_utils "patrol_install/steps/export_artifacts/utils"
 	"patrol_install/steps/install_patrol_cli"
+	cli_source "patrol_install/steps/install_patrol_cli/cli_source"
+	"patrol_install/steps/sharding"
+	"patrol_install/steps/test_inventory"
 	"patrol_install/steps/validate"
 	"patrol_install/utils/print"
 )
 
 func main() {
-	cliVersion, installError := install_patrol_cli.Run(&install_patrol_cli.InstallerRunner{})
+	// Failures are printed where they happen, the exit code tells Bitrise the step failed
+	if err := run(); err != nil {
+		os.Exit(1)
+	}
+}
+
+// run runs the stages in order and returns the first failure, or the build failure once
+// the build report and the platform statuses are exported.
+func run() error {
+	// An invalid source is reported by the installer
+	cliSource, cliSourceError := cli_source.FromEnv()
+	dartVersion, dartError := validate.RunDartCheck(validate.DartCheckRunParams{
+		Runner:              &validate.ValidatorRunner{},
+		RequestedCLIVersion: os.Getenv(build_constants.CustomPatrolCLIVersion),
+		HostedSource:        cliSourceError == nil && cliSource.IsHosted(),
+	})
+	if dartError != nil {
+		print.Error("❌ Validation failed")
+		print.Error(dartError.Error())
+		print.Error("Please check the logs for more details.")
+		return dartError
+	}
+
+	cliDetection, installError := install_patrol_cli.Run(&install_patrol_cli.InstallerRunner{})
 	if installError != nil {
 		print.Error("❌ Setup failed")
 		print.Error(installError.Error())
 		print.Error("Please check the logs for more details.")
-	} else {
-		print.Success("✅ Installing CLI Completed Successfully")
+		return installError
 	}
+	print.Success("✅ Installing CLI Completed Successfully")
+	build_report.Current().SetPatrolCLI(cliDetection.Version.String(), cliDetection.Method)
 
 	validatorParams := validate.ValidatorRunParams{
-		Runner:     &validate.ValidatorRunner{},
-		CliVersion: cliVersion,
+		Runner:       &validate.ValidatorRunner{},
+		CliDetection: cliDetection,
+		DartVersion:  dartVersion,
 	}
 
 	validationError := validate.Run(validatorParams)
@@ -28,23 +69,228 @@ func main() {
 		print.Error("❌ Validation failed")
 		print.Error(validationError.Error())
 		print.Error("Please check the logs for more details.")
-		return
+		return validationError
+	}
+
+	configError := validate.RunConfigCheck(validate.ConfigCheckRunParams{
+		ProjectDir: ".",
+		Platform:   os.Getenv(build_constants.Platform),
+	})
+	if configError != nil {
+		print.Error("❌ Validation failed")
+		print.Error(configError.Error())
+		print.Error("Please check the logs for more details.")
+		return configError
+	}
+
+	inventoryParams := test_inventory.RunParams{
+		ProjectDir:   ".",
+		Target:       os.Getenv(build_constants.TestTargetDirectory),
+		Tags:         os.Getenv(build_constants.Tags),
+		ExcludedTags: os.Getenv(build_constants.ExcludedTags),
+	}
+
+	// Discovery is best effort, without an inventory only sharding fails and reports the scan error
+	inventory, inventoryError := test_inventory.Run(inventoryParams)
+	var scanError *test_inventory.ScanError
+	var discoveryError error
+	if errors.As(inventoryError, &scanError) {
+		discoveryError, inventoryError = inventoryError, nil
+	}
+	if inventoryError != nil {
+		print.Error("❌ Test discovery failed")
+		print.Error(inventoryError.Error())
+		print.Error("Please check the logs for more details.")
+		return inventoryError
+	}
+
+	shardConfig, shardError := sharding.ConfigFromEnv()
+	if shardError != nil {
+		print.Error("❌ Validation failed")
+		print.Error(shardError.Error())
+		return shardError
+	}
+
+	cacheConfig, cacheError := build_cache.ConfigFromEnv()
+	if cacheError != nil {
+		print.Error("❌ Validation failed")
+		print.Error(cacheError.Error())
+		return cacheError
+	}
+
+	hooksConfig, hooksError := build_hooks.ConfigFromEnv()
+	if hooksError != nil {
+		print.Error("❌ Validation failed")
+		print.Error(hooksError.Error())
+		return hooksError
+	}
+
+	continueOnFailure, continueError := build.ContinueOnPlatformFailureFromEnv()
+	if continueError != nil {
+		print.Error("❌ Validation failed")
+		print.Error(continueError.Error())
+		return continueError
+	}
+
+	requireChecks, doctorError := doctor.RequireChecksFromEnv()
+	if doctorError != nil {
+		print.Error("❌ Environment check failed")
+		print.Error(doctorError.Error())
+		return doctorError
+	}
+
+	doctorParams := doctor.DoctorRunParams{
+		Runner:        &doctor.DoctorRunner{},
+		Platform:      os.Getenv(build_constants.Platform),
+		RequireChecks: requireChecks,
+	}
+
+	doctorReport, doctorError := doctor.Run(doctorParams)
+	if doctorError != nil {
+		print.Error("❌ Environment check failed")
+		print.Error(doctorError.Error())
+		print.Error("Please check the logs for more details.")
+		return doctorError
+	}
+
+	var shardingParams *sharding.RunParams
+	if shardConfig.IsEnabled() {
+		shardingParams = &sharding.RunParams{
+			Runner:         &sharding.SharderRunner{ContinueOnPlatformFailure: continueOnFailure},
+			Config:         shardConfig,
+			Inventory:      inventory,
+			InventoryError: discoveryError,
+			Target:         inventoryParams.Target,
+			Tags:           test_inventory.ParseTags(inventoryParams.Tags),
+			ExcludedTags:   test_inventory.ParseTags(inventoryParams.ExcludedTags),
+			Platform:       doctorParams.Platform,
+			ProjectDir:     inventoryParams.ProjectDir,
+
+			ContinueOnPlatformFailure: continueOnFailure,
+		}
+	}
+
+	cacheParams := build_cache.RunParams{
+		Runner:     &build_cache.BuildCacheRunner{CLIVersion: cliDetection.Version, DartVersion: dartVersion},
+		Config:     cacheConfig,
+		ProjectDir: inventoryParams.ProjectDir,
+		Build: func() error {
+			return buildAndExport(shardingParams, doctorReport, continueOnFailure)
+		},
+	}
+
+	// The pre-build script runs before the fingerprint, it may generate code or write config files
+	hooksParams := build_hooks.RunParams{
+		Runner:     &build_hooks.BuildHooksRunner{},
+		Config:     hooksConfig,
+		ProjectDir: inventoryParams.ProjectDir,
+		Build: func() error {
+			return build_cache.Run(cacheParams)
+		},
+	}
+
+	// Build failures are reported by buildAndExport and the hooks
+	buildError := build_hooks.Run(hooksParams)
+	exportBuildReport(inventoryParams.ProjectDir)
+	exportPlatformStatuses(doctorParams.Platform, buildError)
+	return buildError
+}
+
+// buildAndExport builds the tests, as shards when shardingParams is set, and exports the artifacts.
+// With continueOnFailure the platforms that were built are exported even when another platform failed.
+func buildAndExport(shardingParams *sharding.RunParams, doctorReport *doctor_report.DoctorReport, continueOnFailure bool) error {
+	if shardingParams != nil {
+		if _, shardingError := sharding.Run(*shardingParams); shardingError != nil {
+			print.Error(failureTitle(shardingError, "❌ Sharded build failed"))
+			print.Error(shardingError.Error())
+			printDoctorReport(doctorReport)
+			print.Error("Please check the logs for more details.")
+			return shardingError
+		}
+		return nil
 	}
 
 	buildError := build.Run(&build.BuilderRunner{})
 	if buildError != nil {
-		print.Error("❌ Build failed")
+		print.Error(failureTitle(buildError, "❌ Build failed"))
 		print.Error(buildError.Error())
+		printDoctorReport(doctorReport)
 		print.Error("Please check the logs for more details.")
-		return
+		if !continueOnFailure {
+			return buildError
+		}
+		print.Warning(fmt.Sprintf("⚠️ %s is true, exporting the platforms that were built", build_constants.ContinueOnPlatformFailure))
 	}
 
-	exportError := export_artifacts.Run(&export_artifacts.ExporterRunner{})
+	exportError := export_artifacts.Run(&export_artifacts.ExporterRunner{
+		ContinueOnPlatformFailure: continueOnFailure,
+		FailedPlatforms:           build.FailedPlatforms(buildError),
+	})
 	if exportError != nil {
 		print.Error("❌ Export failed")
 		print.Error(exportError.Error())
 		print.Error("Please check the logs for more details.")
+		return errors.Join(buildError, exportError)
+	}
+	return buildError
+}
+
+// exportBuildReport writes the build commands and their attempts, also when the build failed.
+func exportBuildReport(projectDir string) {
+	report := build_report.Current()
+	if report.IsEmpty() {
+		return
+	}
+
+	reportPath := filepath.Join(projectDir, build_report.ReportPath)
+	if err := report.Write(reportPath); err != nil {
+		print.Warning("⚠️ " + err.Error())
 		return
 	}
+	if err := export_artifacts_utils.ExportEnv(build_report.ReportPathEnvKey, reportPath); err != nil {
+		print.Warning(fmt.Sprintf("Error exporting env by Envman %s: %v", build_report.ReportPathEnvKey, err))
+	}
+}
+
+// exportPlatformStatuses exports ANDROID_BUILD_STATUS and IOS_BUILD_STATUS. A selected platform without
+// a recorded status was restored from the cache, or failed before its build command ran.
+func exportPlatformStatuses(platform string, buildError error) {
+	statuses := map[string]string{
+		build_constants.PlatformAndroid: build_report.AndroidStatusEnvKey,
+		build_constants.PlatformIOS:     build_report.IOSStatusEnvKey,
+	}
+	for _, name := range []string{build_constants.PlatformAndroid, build_constants.PlatformIOS} {
+		status := build_report.Current().PlatformStatus(name)
+		switch {
+		case platform != name && platform != build_constants.PlatformBoth:
+			status = build_report.PlatformSkipped
+		case status != "":
+		case buildError != nil:
+			status = build_report.PlatformBuildFailed
+		default:
+			status = build_report.PlatformSucceeded
+		}
 
+		if err := export_artifacts_utils.ExportEnv(statuses[name], status); err != nil {
+			print.Warning(fmt.Sprintf("Error exporting env by Envman %s: %v", statuses[name], err))
+		}
+	}
+}
+
+// failureTitle tells timeouts apart from other build failures.
+func failureTitle(err error, title string) string {
+	var timeoutError *executor.TimeoutError
+	if errors.As(err, &timeoutError) {
+		return "⏱️ Build timed out"
+	}
+	return title
+}
+
+// printDoctorReport prints the environment collected before the build, to help diagnose build failures.
+func printDoctorReport(report *doctor_report.DoctorReport) {
+	if report == nil {
+		return
+	}
+	print.Warning("Build environment reported by patrol doctor:")
+	print.Vanilla(report.Table())
 }
@@ -0,0 +1,55 @@
+package builder
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	export_artifacts_utils "patrol_install/steps/export_artifacts/utils"
+)
+
+const (
+	LogPath       = "patrol/build.log"
+	LogPathEnvKey = "PATROL_BUILD_LOG_PATH"
+
+	// Parallel builds also write the output of each platform to its own log.
+	AndroidLogPath       = "patrol/build_android.log"
+	AndroidLogPathEnvKey = "PATROL_ANDROID_BUILD_LOG_PATH"
+	IOSLogPath           = "patrol/build_ios.log"
+	IOSLogPathEnvKey     = "PATROL_IOS_BUILD_LOG_PATH"
+)
+
+var (
+	logMu      sync.Mutex
+	logStarted = map[string]bool{}
+)
+
+// openBuildLog opens the log at path. The first build of the step run truncates it and exports
+// it as envKey, the next ones, such as other shards, append to it.
+func openBuildLog(path, envKey string) (*os.File, error) {
+	logMu.Lock()
+	defer logMu.Unlock()
+
+	flags := os.O_CREATE | os.O_WRONLY | os.O_APPEND
+	if !logStarted[path] {
+		flags |= os.O_TRUNC
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create folder %s: %w", filepath.Dir(path), err)
+	}
+	file, err := os.OpenFile(path, flags, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open build log %s: %w", path, err)
+	}
+
+	if !logStarted[path] {
+		logStarted[path] = true
+		if err := export_artifacts_utils.ExportEnv(envKey, path); err != nil {
+			file.Close()
+			return nil, fmt.Errorf("error exporting env by Envman %s: %w", envKey, err)
+		}
+	}
+	return file, nil
+}
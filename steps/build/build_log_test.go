@@ -0,0 +1,59 @@
+package builder
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	export_artifacts_utils "patrol_install/steps/export_artifacts/utils"
+)
+
+type exporterSpy struct {
+	exported map[string]string
+}
+
+func (s *exporterSpy) Export(key, value string) error {
+	s.exported[key] = value
+	return nil
+}
+
+func TestOpenBuildLog_TruncatesOncePerRun(t *testing.T) {
+	spy := &exporterSpy{exported: map[string]string{}}
+	export_artifacts_utils.SetEnvExporter(spy)
+	t.Cleanup(func() {
+		export_artifacts_utils.SetEnvExporter(nil)
+	})
+
+	// GIVEN a log left by a previous step run
+	path := filepath.Join(t.TempDir(), LogPath)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte("previous run\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// WHEN two builds of this run write to it
+	for _, line := range []string{"shard 0\n", "shard 1\n"} {
+		log, err := openBuildLog(path, LogPathEnvKey)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if _, err := log.WriteString(line); err != nil {
+			t.Fatal(err)
+		}
+		log.Close()
+	}
+
+	// THEN the previous run is dropped, both builds are kept and the path is exported
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "shard 0\nshard 1\n" {
+		t.Errorf("log = %q", data)
+	}
+	if spy.exported[LogPathEnvKey] != path {
+		t.Errorf("exported %s = %q, want %q", LogPathEnvKey, spy.exported[LogPathEnvKey], path)
+	}
+}
@@ -0,0 +1,138 @@
+package build_report
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+const (
+	ReportPath       = "patrol/build_report.json"
+	ReportPathEnvKey = "PATROL_BUILD_REPORT_PATH"
+
+	StatusSucceeded = "succeeded"
+	StatusFailed    = "failed"
+	// StatusCanceled is a platform build stopped because the other platform failed.
+	StatusCanceled = "canceled"
+
+	// Platform statuses, from best to worst.
+	PlatformSucceeded    = "succeeded"
+	PlatformExportFailed = "export_failed"
+	PlatformBuildFailed  = "build_failed"
+	// PlatformSkipped is a platform not selected by PLATFORM.
+	PlatformSkipped = "skipped"
+
+	AndroidStatusEnvKey = "ANDROID_BUILD_STATUS"
+	IOSStatusEnvKey     = "IOS_BUILD_STATUS"
+)
+
+// platformSeverity orders the statuses, so a failure isn't replaced by the success of a later shard.
+var platformSeverity = map[string]int{
+	PlatformSucceeded:    1,
+	PlatformExportFailed: 2,
+	PlatformBuildFailed:  3,
+}
+
+// Attempt is one run of a build command.
+type Attempt struct {
+	Number          int     `json:"number"`
+	DurationSeconds float64 `json:"durationSeconds"`
+	Error           string  `json:"error,omitempty"`
+	// FlakyClass is the transient failure class matched in the output, it's why the command was retried.
+	FlakyClass string `json:"flakyClass,omitempty"`
+}
+
+// CommandReport is a build command with all its attempts.
+type CommandReport struct {
+	Command  string    `json:"command"`
+	Status   string    `json:"status"`
+	Attempts []Attempt `json:"attempts"`
+}
+
+// ToolReport is a tool version used by the build and how it was detected.
+type ToolReport struct {
+	Version     string `json:"version"`
+	DetectedVia string `json:"detectedVia"`
+}
+
+// BuildReport describes the build commands run by the step. It is safe for concurrent use.
+type BuildReport struct {
+	mu sync.Mutex
+	// PatrolCLI is the Patrol CLI the commands were run with.
+	PatrolCLI *ToolReport     `json:"patrolCLI,omitempty"`
+	Commands  []CommandReport `json:"commands"`
+	// Platforms holds the status of each platform built by the step.
+	Platforms map[string]string `json:"platforms,omitempty"`
+}
+
+// current collects the commands of the whole step run, across shards.
+var current = &BuildReport{}
+
+// Current returns the report of this step run.
+func Current() *BuildReport {
+	return current
+}
+
+// Reset starts an empty report, for tests.
+func Reset() {
+	current = &BuildReport{}
+}
+
+// Add records a finished command.
+func (r *BuildReport) Add(command CommandReport) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.Commands = append(r.Commands, command)
+}
+
+// SetPatrolCLI records the Patrol CLI version and the method that detected it.
+func (r *BuildReport) SetPatrolCLI(version, detectedVia string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.PatrolCLI = &ToolReport{Version: version, DetectedVia: detectedVia}
+}
+
+// SetPlatformStatus records the status of a platform, unless a worse status was recorded before.
+func (r *BuildReport) SetPlatformStatus(platform, status string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.Platforms == nil {
+		r.Platforms = map[string]string{}
+	}
+	if platformSeverity[status] > platformSeverity[r.Platforms[platform]] {
+		r.Platforms[platform] = status
+	}
+}
+
+// PlatformStatus returns the status of a platform, empty when nothing was recorded for it.
+func (r *BuildReport) PlatformStatus(platform string) string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.Platforms[platform]
+}
+
+// IsEmpty reports whether no command was recorded, for example when the build was restored from the cache.
+func (r *BuildReport) IsEmpty() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.Commands) == 0 && len(r.Platforms) == 0
+}
+
+// Write saves the report as indented JSON, creating the parent directory.
+func (r *BuildReport) Write(path string) error {
+	r.mu.Lock()
+	data, err := json.MarshalIndent(r, "", "  ")
+	r.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create folder %s: %w", filepath.Dir(path), err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+package build_report
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWrite(t *testing.T) {
+	report := &BuildReport{}
+	if !report.IsEmpty() {
+		t.Fatal("expected an empty report")
+	}
+
+	report.SetPatrolCLI("3.9.0", "dart pub global list")
+	if !report.IsEmpty() {
+		t.Fatal("expected a report without commands to be empty")
+	}
+
+	report.Add(CommandReport{
+		Command: "patrol build android",
+		Status:  StatusSucceeded,
+		Attempts: []Attempt{
+			{Number: 1, DurationSeconds: 12, Error: "command failed: exit status 1", FlakyClass: "gradle-cache-lock"},
+			{Number: 2, DurationSeconds: 95},
+		},
+	})
+
+	path := filepath.Join(t.TempDir(), ReportPath)
+	if err := report.Write(path); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read the report: %v", err)
+	}
+	var written BuildReport
+	if err := json.Unmarshal(data, &written); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if len(written.Commands) != 1 || written.Commands[0].Attempts[0].FlakyClass != "gradle-cache-lock" {
+		t.Errorf("unexpected report %s", data)
+	}
+	if written.PatrolCLI == nil || written.PatrolCLI.Version != "3.9.0" || written.PatrolCLI.DetectedVia != "dart pub global list" {
+		t.Errorf("expected the Patrol CLI detection in the report, got %s", data)
+	}
+}
+
+func TestSetPlatformStatus_KeepsWorstStatus(t *testing.T) {
+	report := &BuildReport{}
+
+	// GIVEN a first shard whose android build failed
+	report.SetPlatformStatus("android", PlatformBuildFailed)
+	report.SetPlatformStatus("ios", PlatformSucceeded)
+
+	// WHEN the next shard succeeds and the ios export fails
+	report.SetPlatformStatus("android", PlatformSucceeded)
+	report.SetPlatformStatus("ios", PlatformExportFailed)
+
+	// THEN the failures are kept
+	if got := report.PlatformStatus("android"); got != PlatformBuildFailed {
+		t.Errorf("android status = %q, want %q", got, PlatformBuildFailed)
+	}
+	if got := report.PlatformStatus("ios"); got != PlatformExportFailed {
+		t.Errorf("ios status = %q, want %q", got, PlatformExportFailed)
+	}
+}
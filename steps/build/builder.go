@@ -1,14 +1,20 @@
 package builder
 
 import (
-	"bufio"
+	"errors"
 	"fmt"
-	"io"
-	"os/exec"
+	"strings"
 
+	build_report "patrol_install/steps/build/build_report"
+	constants "patrol_install/steps/build/constants"
+	"patrol_install/steps/build/executor"
+	failure_analyzer "patrol_install/steps/build/failure_analyzer"
 	"patrol_install/utils/print"
 )
 
+// failureTailLines is the number of output lines printed again when a command fails.
+const failureTailLines = 200
+
 type Builder interface {
 	BuildParametersFromEnv() ([]string, error)
 }
@@ -23,55 +29,117 @@ func Run(installer Builder) error {
 		return err
 	}
 
+	options, err := OptionsFromEnv()
+	if err != nil {
+		print.Error(fmt.Sprintf("❌ Invalid build timeouts: %s", err))
+		return err
+	}
+
+	policy, err := RetryPolicyFromEnv()
+	if err != nil {
+		print.Error(fmt.Sprintf("❌ Invalid build retries: %s", err))
+		return err
+	}
+
+	prefixPlatform, err := platformPrefixFromEnv()
+	if err != nil {
+		print.Error(fmt.Sprintf("❌ Invalid output settings: %s", err))
+		return err
+	}
+
+	// The build log is a convenience, the build goes on without it
+	if log, err := openBuildLog(LogPath, LogPathEnvKey); err != nil {
+		print.Warning("⚠️ " + err.Error())
+	} else {
+		defer log.Close()
+		options.Log = log
+	}
+
+	parallel, err := boolFromEnv(constants.ParallelBuilds)
+	if err != nil {
+		print.Error(fmt.Sprintf("❌ Invalid build settings: %s", err))
+		return err
+	}
+	continueOnFailure, err := ContinueOnPlatformFailureFromEnv()
+	if err != nil {
+		print.Error(fmt.Sprintf("❌ Invalid build settings: %s", err))
+		return err
+	}
+
+	if parallel && len(commands) > 1 {
+		if err := runParallel(commands, options, policy, continueOnFailure); err != nil {
+			return err
+		}
+		print.StepCompleted("✅ All build commands executed successfully.")
+		return nil
+	}
+	if parallel {
+		print.Warning(fmt.Sprintf("⚠️ %s only applies when %s is %s, building sequentially",
+			constants.ParallelBuilds, constants.Platform, constants.PlatformBoth))
+	}
+
+	var failures []error
 	for _, cmd := range commands {
 		print.Action(fmt.Sprintf("Executing build command: %s", cmd))
 
-		if err := executeCommand(cmd); err != nil {
+		commandOptions := options
+		if prefixPlatform {
+			commandOptions.Prefix = platformPrefix(cmd)
+		}
+		result, attempts, err := runWithRetry(cmd, commandOptions, policy)
+		recordCommand(cmd, attempts, err)
+		if err != nil {
+			printFailure(result)
 			print.Error(fmt.Sprintf("❌ Command failed: %s\n", err))
-			return fmt.Errorf("build aborted: failed to execute '%s': %w", cmd, err)
+			if !continueOnFailure {
+				return &PlatformError{Platform: commandPlatform(cmd), Err: fmt.Errorf("build aborted: failed to execute '%s': %w", cmd, err)}
+			}
+			failures = append(failures, &PlatformError{Platform: commandPlatform(cmd), Err: fmt.Errorf("failed to execute '%s': %w", cmd, err)})
+			print.Warning(fmt.Sprintf("⚠️ %s is true, continuing with the next build command", constants.ContinueOnPlatformFailure))
+			continue
 		}
 
 		print.Success(fmt.Sprintf("✅ Command '%s' executed successfully.\n", cmd))
 	}
+	if len(failures) > 0 {
+		return errors.Join(failures...)
+	}
 
 	print.StepCompleted("✅ All build commands executed successfully.")
 	return nil
 }
 
-func executeCommand(command string) error {
-	// Use 'sh -c' to allow complex shell expressions
-	cmd := exec.Command("sh", "-c", command)
-
-	stdoutPipe, err := cmd.StdoutPipe()
-	if err != nil {
-		return fmt.Errorf("failed to get stdout: %w", err)
-	}
-
-	stderrPipe, err := cmd.StderrPipe()
-	if err != nil {
-		return fmt.Errorf("failed to get stderr: %w", err)
+// printFailure prints the probable causes found in the output, above its last lines.
+func printFailure(result *executor.Result) {
+	if findings := failure_analyzer.Analyze(result.Output); len(findings) > 0 {
+		print.Warning("Probable cause:")
+		for _, finding := range findings {
+			print.Warning("👉 " + finding.String())
+		}
 	}
 
-	// Start the command
-	if err := cmd.Start(); err != nil {
-		return fmt.Errorf("failed to start command: %w", err)
+	tail := result.Output[max(len(result.Output)-failureTailLines, 0):]
+	if len(tail) > 0 {
+		print.Warning(fmt.Sprintf("Last %d lines of output:", len(tail)))
+		print.Vanilla(strings.Join(tail, "\n"))
 	}
+}
 
-	// Stream output in real time
-	go streamOutput(stdoutPipe)
-	go streamOutput(stderrPipe)
-
-	// Wait for the command to complete
-	if err := cmd.Wait(); err != nil {
-		return fmt.Errorf("command failed: %w", err)
+// recordCommand adds the command and its attempts to the build report of the step run.
+func recordCommand(command string, attempts []build_report.Attempt, err error) {
+	status := build_report.StatusSucceeded
+	if errors.Is(err, executor.ErrCanceled) {
+		status = build_report.StatusCanceled
+	} else if err != nil {
+		status = build_report.StatusFailed
 	}
+	build_report.Current().Add(build_report.CommandReport{Command: command, Status: status, Attempts: attempts})
 
-	return nil
-}
-
-func streamOutput(pipe io.ReadCloser) {
-	scanner := bufio.NewScanner(pipe)
-	for scanner.Scan() {
-		fmt.Println(scanner.Text())
+	if platform := commandPlatform(command); platform != "" {
+		platformStatus := build_report.PlatformSucceeded
+		if err != nil {
+			platformStatus = build_report.PlatformBuildFailed
+		}
+		build_report.Current().SetPlatformStatus(platform, platformStatus)
 	}
 }
@@ -2,6 +2,7 @@ package builder
 
 import (
 	"fmt"
+	"strings"
 
 	getEnv "patrol_install/steps/build/steps/create_parameters"
 	"patrol_install/utils/print"
@@ -24,3 +25,20 @@ func (p *BuilderRunner) BuildParametersFromEnv() ([]string, error) {
 
 	return finalCommand, nil
 }
+
+// ShardBuilderRunner builds the given test files, one shard, instead of the whole TEST_TARGET_DIRECTORY.
+type ShardBuilderRunner struct {
+	Files []string
+}
+
+func (p *ShardBuilderRunner) BuildParametersFromEnv() ([]string, error) {
+	command, err := getEnv.BuildParametersFromEnv()
+	if err != nil {
+		print.Error(fmt.Sprintf("Build failed: %s", err))
+		return []string{}, err
+	}
+
+	// patrol build accepts a comma separated list of targets
+	command.Target = strings.Join(p.Files, ",")
+	return command.Command(), nil
+}
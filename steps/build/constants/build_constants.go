@@ -1,13 +1,38 @@
 package build_constants
 
 const (
-	CustomPatrolCLIVersion = "CUSTOM_PATROL_CLI_VERSION" // Optional, using latest when empty
-	TestTargetDirectory    = "TEST_TARGET_DIRECTORY"     // Required
-	Platform               = "PLATFORM"                  // Required, using both as default
-	BuildType              = "TEST_BUILD_TYPE"           // Required, using release as default
-	Tags                   = "TAGS"                      // optional, using empty string as default
-	ExcludedTags           = "EXCLUDED_TAGS"             // optional, using empty string as default
-	IsVerboseMode          = "IS_VERBOSE_MODE"           // optional, using false as default
+	CustomPatrolCLIVersion    = "CUSTOM_PATROL_CLI_VERSION"    // Optional, using latest when empty
+	PatrolCLISource           = "PATROL_CLI_SOURCE"            // optional, using hosted as default
+	PatrolCLIGitURL           = "PATROL_CLI_GIT_URL"           // required when PATROL_CLI_SOURCE is git
+	PatrolCLIGitRef           = "PATROL_CLI_GIT_REF"           // optional, using the default branch when empty
+	PatrolCLIGitPath          = "PATROL_CLI_GIT_PATH"          // optional, package path inside the git repository
+	PatrolCLIPath             = "PATROL_CLI_PATH"              // required when PATROL_CLI_SOURCE is path
+	PubHostedURL              = "PUB_HOSTED_URL"               // optional, using pub.dev when empty
+	InstallRetries            = "INSTALL_RETRIES"              // optional, using 2 as default
+	TestTargetDirectory       = "TEST_TARGET_DIRECTORY"        // Required
+	Platform                  = "PLATFORM"                     // Required, using both as default
+	BuildType                 = "TEST_BUILD_TYPE"              // Required, using release as default
+	Tags                      = "TAGS"                         // optional, using empty string as default
+	ExcludedTags              = "EXCLUDED_TAGS"                // optional, using empty string as default
+	IsVerboseMode             = "IS_VERBOSE_MODE"              // optional, using false as default
+	RequireDoctorChecks       = "REQUIRE_DOCTOR_CHECKS"        // optional, using false as default
+	ShardCount                = "SHARD_COUNT"                  // optional, using 1 (no sharding) as default
+	ShardStrategy             = "SHARD_STRATEGY"               // optional, using round-robin as default
+	ShardTimingsDir           = "SHARD_TIMINGS_DIR"            // optional, JUnit XML of previous runs for by-timing
+	BuildCache                = "BUILD_CACHE"                  // optional, using false as default
+	BuildCacheDir             = "BUILD_CACHE_DIR"              // optional, using $HOME/.patrol_build_cache as default
+	BuildTimeout              = "BUILD_TIMEOUT"                // optional, minutes or a duration such as 1h30m, disabled when empty
+	NoOutputTimeout           = "NO_OUTPUT_TIMEOUT"            // optional, minutes or a duration such as 20m, disabled when empty
+	BuildRetries              = "BUILD_RETRIES"                // optional, using 1 as default
+	BuildRetryPatterns        = "BUILD_RETRY_PATTERNS"         // optional, extra transient failure regexes, one per line
+	OutputPlatformPrefix      = "OUTPUT_PLATFORM_PREFIX"       // optional, using false as default
+	OutputElapsedPrefix       = "OUTPUT_ELAPSED_PREFIX"        // optional, using false as default
+	ParallelBuilds            = "PARALLEL_BUILDS"              // optional, using false as default
+	ContinueOnPlatformFailure = "CONTINUE_ON_PLATFORM_FAILURE" // optional, using false as default
+	PreBuildScript            = "PRE_BUILD_SCRIPT"             // optional, inline script or path
+	PreBuildScriptOnFailure   = "PRE_BUILD_SCRIPT_ON_FAILURE"  // optional, using fail as default
+	PostBuildScript           = "POST_BUILD_SCRIPT"            // optional, inline script or path
+	PostBuildScriptOnFailure  = "POST_BUILD_SCRIPT_ON_FAILURE" // optional, using fail as default
 
 	PlatformAndroid = "android"
 	PlatformIOS     = "ios"
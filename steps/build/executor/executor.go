@@ -0,0 +1,243 @@
+package executor
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"patrol_install/utils/print"
+)
+
+const (
+	defaultHeartbeatInterval = time.Minute
+	defaultKillGracePeriod   = 10 * time.Second
+)
+
+// watchInterval is how often the timeouts are checked, a variable so tests can shorten it.
+var watchInterval = time.Second
+
+// outputCloseDelay is how long the output pipes may stay open after the shell exited, a variable
+// so tests can shorten it. A daemon started by the build, or a child that left the process group,
+// inherits the pipes and would otherwise keep the step waiting for their end forever.
+var outputCloseDelay = 10 * time.Second
+
+// Options configures how a build command is run. Zero timeouts are disabled.
+type Options struct {
+	// Timeout limits the total duration of the command.
+	Timeout time.Duration
+	// NoOutputTimeout limits how long the command may run without printing anything.
+	NoOutputTimeout time.Duration
+	// HeartbeatInterval is how long output may be quiet before a "still building" line is printed.
+	HeartbeatInterval time.Duration
+	// KillGracePeriod is how long to wait after SIGTERM before sending SIGKILL.
+	KillGracePeriod time.Duration
+	// BufferLines is the number of output lines kept in the Result.
+	BufferLines int
+	// Prefix is printed before every line, such as "[android]".
+	Prefix string
+	// Elapsed prints the time since the command started before every line.
+	Elapsed bool
+	// Output receives the printed lines, os.Stdout by default.
+	Output io.Writer
+	// Log receives the printed lines as well, when set.
+	Log io.Writer
+	// Cancel stops the command like a timeout when it is closed, such as when another platform failed.
+	Cancel <-chan struct{}
+	// Dir is the working directory of the command, the current one when empty.
+	Dir string
+	// Env is added to the environment of the step, as KEY=value entries.
+	Env []string
+}
+
+// ErrCanceled is returned when a command is stopped through Options.Cancel.
+var ErrCanceled = errors.New("build canceled")
+
+// Result is what a command left behind, also when it failed.
+type Result struct {
+	// Output holds the last BufferLines lines of output.
+	Output   []string
+	Duration time.Duration
+}
+
+// TimeoutError is returned when a command is stopped by one of the timeouts.
+type TimeoutError struct {
+	// NoOutput is true when the command was stopped by NoOutputTimeout.
+	NoOutput bool
+	Limit    time.Duration
+}
+
+func (e *TimeoutError) Error() string {
+	if e.NoOutput {
+		return fmt.Sprintf("build timed out: no output for %s", e.Limit)
+	}
+	return fmt.Sprintf("build timed out after %s", e.Limit)
+}
+
+// Execute runs the command with `sh -c`, streaming its output, and stops the whole process
+// group when a timeout expires: SIGTERM first, then SIGKILL after the grace period.
+func Execute(command string, options Options) (*Result, error) {
+	options = withDefaults(options)
+
+	// Use 'sh -c' to allow complex shell expressions
+	cmd := exec.Command("sh", "-c", command)
+	cmd.Dir = options.Dir
+	if len(options.Env) > 0 {
+		cmd.Env = append(os.Environ(), options.Env...)
+	}
+	configureProcessGroup(cmd)
+
+	started := time.Now()
+	output := NewRingBuffer(options.BufferLines)
+	result := func() *Result {
+		return &Result{Output: output.Lines(), Duration: time.Since(started)}
+	}
+
+	// exec copies the output into the pipes and, WaitDelay after the shell exited, closes
+	// the output it copies from even when a descendant still holds it
+	stdoutReader, stdoutWriter := io.Pipe()
+	stderrReader, stderrWriter := io.Pipe()
+	cmd.Stdout = stdoutWriter
+	cmd.Stderr = stderrWriter
+	cmd.WaitDelay = outputCloseDelay
+
+	// Start the command
+	if err := cmd.Start(); err != nil {
+		return result(), fmt.Errorf("failed to start command: %w", err)
+	}
+
+	var lastOutput atomic.Int64
+	lastOutput.Store(started.UnixNano())
+
+	mux := &multiplexer{
+		output:  options.Output,
+		log:     options.Log,
+		prefix:  options.Prefix,
+		elapsed: options.Elapsed,
+		started: started,
+		onLine: func(line string) {
+			lastOutput.Store(time.Now().UnixNano())
+			output.Add(line)
+		},
+	}
+
+	// Stream output in real time, the pipes are closed once the command was waited for
+	var streams sync.WaitGroup
+	for _, pipe := range []io.Reader{stdoutReader, stderrReader} {
+		streams.Add(1)
+		go func(pipe io.Reader) {
+			defer streams.Done()
+			_ = mux.stream(pipe)
+		}(pipe)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		err := cmd.Wait()
+		_ = stdoutWriter.Close()
+		_ = stderrWriter.Close()
+		streams.Wait()
+		if errors.Is(err, exec.ErrWaitDelay) {
+			// The command itself succeeded, only its leftover descendants kept the output open
+			print.Warning(withPrefix(options.Prefix, fmt.Sprintf("Output still open %s after the build exited, a leftover process holds it", outputCloseDelay)))
+			err = nil
+		}
+		done <- err
+	}()
+
+	stopErr := watch(done, started, &lastOutput, options)
+	if stopErr == nil {
+		if err := <-done; err != nil {
+			return result(), fmt.Errorf("command failed: %w", err)
+		}
+		return result(), nil
+	}
+
+	if errors.Is(stopErr, ErrCanceled) {
+		print.Warning(withPrefix(options.Prefix, "Stopping the build, it was canceled"))
+	} else {
+		print.Error("❌ " + withPrefix(options.Prefix, stopErr.Error()+", stopping the build"))
+	}
+	stop(cmd, done, options)
+	return result(), stopErr
+}
+
+// watch waits for the command, printing heartbeats, and returns a TimeoutError when a timeout
+// expires first, or ErrCanceled when the command is canceled. On success the result is left in done.
+func watch(done chan error, started time.Time, lastOutput *atomic.Int64, options Options) error {
+	ticker := time.NewTicker(watchInterval)
+	defer ticker.Stop()
+	lastHeartbeat := started
+
+	for {
+		select {
+		case err := <-done:
+			done <- err
+			return nil
+		case <-options.Cancel:
+			return ErrCanceled
+		case now := <-ticker.C:
+			quiet := now.Sub(time.Unix(0, lastOutput.Load()))
+
+			if options.Timeout > 0 && now.Sub(started) >= options.Timeout {
+				return &TimeoutError{Limit: options.Timeout}
+			}
+			if options.NoOutputTimeout > 0 && quiet >= options.NoOutputTimeout {
+				return &TimeoutError{NoOutput: true, Limit: options.NoOutputTimeout}
+			}
+			if quiet >= options.HeartbeatInterval && now.Sub(lastHeartbeat) >= options.HeartbeatInterval {
+				lastHeartbeat = now
+				print.Action(withPrefix(options.Prefix, fmt.Sprintf("still building… (%s)", formatElapsed(now.Sub(started)))))
+			}
+		}
+	}
+}
+
+// stop sends SIGTERM to the process group, then SIGKILL if it is still running after the grace period.
+func stop(cmd *exec.Cmd, done chan error, options Options) {
+	terminateProcessGroup(cmd)
+	select {
+	case <-done:
+		return
+	case <-time.After(options.KillGracePeriod):
+	}
+
+	print.Warning(withPrefix(options.Prefix, fmt.Sprintf("Build still running %s after SIGTERM, sending SIGKILL", options.KillGracePeriod)))
+	killProcessGroup(cmd)
+	<-done
+}
+
+// formatElapsed formats a duration as minutes, or seconds below a minute, e.g. "12m" or "45s".
+func formatElapsed(elapsed time.Duration) string {
+	if elapsed < time.Minute {
+		return elapsed.Round(time.Second).String()
+	}
+	return fmt.Sprintf("%dm", int(elapsed.Minutes()))
+}
+
+func withPrefix(prefix, message string) string {
+	if prefix == "" {
+		return message
+	}
+	return prefix + " " + message
+}
+
+func withDefaults(options Options) Options {
+	if options.HeartbeatInterval <= 0 {
+		options.HeartbeatInterval = defaultHeartbeatInterval
+	}
+	if options.KillGracePeriod <= 0 {
+		options.KillGracePeriod = defaultKillGracePeriod
+	}
+	if options.BufferLines <= 0 {
+		options.BufferLines = DefaultBufferLines
+	}
+	if options.Output == nil {
+		options.Output = os.Stdout
+	}
+	return options
+}
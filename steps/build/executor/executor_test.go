@@ -0,0 +1,180 @@
+package executor
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func shortWatch(t *testing.T) {
+	t.Helper()
+	previous := watchInterval
+	watchInterval = 10 * time.Millisecond
+	t.Cleanup(func() {
+		watchInterval = previous
+	})
+}
+
+func shortOutputCloseDelay(t *testing.T) {
+	t.Helper()
+	previous := outputCloseDelay
+	outputCloseDelay = 100 * time.Millisecond
+	t.Cleanup(func() {
+		outputCloseDelay = previous
+	})
+}
+
+func TestRingBuffer_KeepsLastLines(t *testing.T) {
+	buffer := NewRingBuffer(3)
+	if got := buffer.Lines(); len(got) != 0 {
+		t.Fatalf("expected an empty buffer, got %v", got)
+	}
+
+	for i := 1; i <= 5; i++ {
+		buffer.Add(fmt.Sprintf("line %d", i))
+	}
+
+	got := strings.Join(buffer.Lines(), ",")
+	if got != "line 3,line 4,line 5" {
+		t.Errorf("Lines() = %q", got)
+	}
+}
+
+func TestExecute_Succeeds(t *testing.T) {
+	shortWatch(t)
+	result, err := Execute("echo out; echo err >&2", Options{Timeout: time.Minute})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(result.Output) != 2 {
+		t.Errorf("expected both output lines in the output, got %v", result.Output)
+	}
+}
+
+func TestExecute_ReturnsWhileADescendantHoldsTheOutput(t *testing.T) {
+	shortWatch(t)
+	shortOutputCloseDelay(t)
+
+	// GIVEN a command that exits but leaves a background process writing to its output
+	started := time.Now()
+	result, err := Execute("sleep 3 & echo built", Options{})
+
+	// THEN the command returns shortly after the shell exited, with its output
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if elapsed := time.Since(started); elapsed >= 2*time.Second {
+		t.Errorf("expected Execute to return before the background process ends, took %s", elapsed)
+	}
+	if len(result.Output) != 1 || result.Output[0] != "built" {
+		t.Errorf("unexpected output %v", result.Output)
+	}
+}
+
+func TestExecute_ReportsFailure(t *testing.T) {
+	shortWatch(t)
+	_, err := Execute("exit 3", Options{})
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	var timeoutErr *TimeoutError
+	if errors.As(err, &timeoutErr) {
+		t.Errorf("expected a plain failure, got %v", err)
+	}
+}
+
+func TestExecute_Timeout(t *testing.T) {
+	shortWatch(t)
+	started := time.Now()
+
+	// GIVEN a command which keeps printing but never ends
+	_, err := Execute("while true; do echo building; sleep 0.05; done", Options{Timeout: 200 * time.Millisecond})
+
+	// THEN it is stopped with a timeout error
+	var timeoutErr *TimeoutError
+	if !errors.As(err, &timeoutErr) || timeoutErr.NoOutput {
+		t.Fatalf("expected a build timeout, got %v", err)
+	}
+	if elapsed := time.Since(started); elapsed > 5*time.Second {
+		t.Errorf("expected the command to be stopped quickly, took %s", elapsed)
+	}
+}
+
+func TestExecute_NoOutputTimeoutStopsProcessGroup(t *testing.T) {
+	shortWatch(t)
+	started := time.Now()
+
+	// GIVEN a quiet command with a child process holding the pipes open
+	_, err := Execute("echo start; sleep 30 & sleep 30", Options{NoOutputTimeout: 200 * time.Millisecond})
+
+	// THEN the whole group is stopped with a no output timeout
+	var timeoutErr *TimeoutError
+	if !errors.As(err, &timeoutErr) || !timeoutErr.NoOutput {
+		t.Fatalf("expected a no output timeout, got %v", err)
+	}
+	if elapsed := time.Since(started); elapsed > 5*time.Second {
+		t.Errorf("expected the process group to be stopped quickly, took %s", elapsed)
+	}
+}
+
+func TestExecute_KillsAfterGracePeriod(t *testing.T) {
+	shortWatch(t)
+
+	// GIVEN a command ignoring SIGTERM
+	_, err := Execute("trap '' TERM; echo start; sleep 30", Options{
+		NoOutputTimeout: 100 * time.Millisecond,
+		KillGracePeriod: 100 * time.Millisecond,
+	})
+
+	// THEN it is killed
+	var timeoutErr *TimeoutError
+	if !errors.As(err, &timeoutErr) {
+		t.Fatalf("expected a timeout, got %v", err)
+	}
+}
+
+func TestFormatElapsed(t *testing.T) {
+	tests := map[time.Duration]string{
+		45 * time.Second:                "45s",
+		12*time.Minute + 30*time.Second: "12m",
+		90 * time.Minute:                "90m",
+	}
+	for elapsed, want := range tests {
+		if got := formatElapsed(elapsed); got != want {
+			t.Errorf("formatElapsed(%s) = %q, want %q", elapsed, got, want)
+		}
+	}
+}
+
+func TestExecute_Cancel(t *testing.T) {
+	shortWatch(t)
+	cancel := make(chan struct{})
+	time.AfterFunc(100*time.Millisecond, func() {
+		close(cancel)
+	})
+
+	_, err := Execute("echo start; sleep 30", Options{Cancel: cancel})
+	if !errors.Is(err, ErrCanceled) {
+		t.Fatalf("expected ErrCanceled, got %v", err)
+	}
+}
+
+func TestExecute_DirAndEnv(t *testing.T) {
+	shortWatch(t)
+	dir := t.TempDir()
+
+	result, err := Execute(`echo "$PATROL_BUILD_PLATFORM in $(pwd)"`, Options{
+		Dir:    dir,
+		Env:    []string{"PATROL_BUILD_PLATFORM=android"},
+		Output: io.Discard,
+	})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if want := "android in " + dir; len(result.Output) != 1 || !strings.HasSuffix(result.Output[0], want) {
+		t.Errorf("output = %v, want %q", result.Output, want)
+	}
+}
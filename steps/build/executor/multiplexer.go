@@ -0,0 +1,79 @@
+package executor
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+)
+
+// multiplexer writes the lines of stdout and stderr to the output and the log,
+// one whole line at a time so the two pipes never interleave within a line.
+type multiplexer struct {
+	mu      sync.Mutex
+	output  io.Writer
+	log     io.Writer
+	prefix  string
+	elapsed bool
+	started time.Time
+	// onLine receives every line without prefixes.
+	onLine func(line string)
+}
+
+// stream copies the pipe line by line until it is closed. Lines may be arbitrarily long.
+func (m *multiplexer) stream(pipe io.Reader) error {
+	reader := bufio.NewReader(pipe)
+	for {
+		line, err := reader.ReadString('\n')
+		if line != "" {
+			m.writeLine(strings.TrimRight(line, "\r\n"))
+		}
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			// Keep draining, so the command never blocks on a full pipe
+			_, _ = io.Copy(io.Discard, pipe)
+			return err
+		}
+	}
+}
+
+func (m *multiplexer) writeLine(line string) {
+	formatted := m.format(line) + "\n"
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.onLine(line)
+	_, _ = io.WriteString(m.output, formatted)
+	if m.log != nil {
+		// A failing log must not fail the build, the output is still printed
+		_, _ = io.WriteString(m.log, formatted)
+	}
+}
+
+func (m *multiplexer) format(line string) string {
+	var prefixes []string
+	if m.prefix != "" {
+		prefixes = append(prefixes, m.prefix)
+	}
+	if m.elapsed {
+		prefixes = append(prefixes, "["+formatClock(time.Since(m.started))+"]")
+	}
+	if len(prefixes) == 0 {
+		return line
+	}
+	return strings.Join(prefixes, " ") + " " + line
+}
+
+// formatClock formats a duration as m:ss, or h:mm:ss from an hour.
+func formatClock(elapsed time.Duration) string {
+	seconds := int(elapsed.Seconds())
+	if seconds >= 3600 {
+		return fmt.Sprintf("%d:%02d:%02d", seconds/3600, seconds/60%60, seconds%60)
+	}
+	return fmt.Sprintf("%d:%02d", seconds/60, seconds%60)
+}
@@ -0,0 +1,67 @@
+package executor
+
+import (
+	"bytes"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestExecute_StreamsEveryLine(t *testing.T) {
+	shortWatch(t)
+	var output, log bytes.Buffer
+
+	// GIVEN a command whose last lines are printed right before it exits, and a line over 64KB
+	command := "head -c 100000 /dev/zero | tr '\\0' 'a'; echo; for i in 1 2 3; do echo out $i; echo err $i >&2; done; printf last"
+	result, err := Execute(command, Options{Output: &output, Log: &log})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	// THEN every line is printed, logged and kept, including the long one
+	// stdout and stderr are concurrent, only the order within a pipe is known
+	lines := strings.Split(strings.TrimSuffix(output.String(), "\n"), "\n")
+	if len(lines) != 8 || !slices.Contains(lines, strings.Repeat("a", 100000)) || !slices.Contains(lines, "last") || !slices.Contains(lines, "err 3") {
+		t.Errorf("unexpected output: %d lines", len(lines))
+	}
+	if log.String() != output.String() {
+		t.Error("expected the log to match the output")
+	}
+	if len(result.Output) != 8 {
+		t.Errorf("expected 8 lines in the result, got %d", len(result.Output))
+	}
+}
+
+func TestMultiplexer_Prefixes(t *testing.T) {
+	var output bytes.Buffer
+	mux := &multiplexer{
+		output:  &output,
+		prefix:  "[ios]",
+		elapsed: true,
+		started: time.Now().Add(-75 * time.Second),
+		onLine:  func(string) {},
+	}
+
+	if err := mux.stream(strings.NewReader("Running pod install...\r\nXcode build done.\n")); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	want := "[ios] [1:15] Running pod install...\n[ios] [1:15] Xcode build done.\n"
+	if output.String() != want {
+		t.Errorf("output = %q, want %q", output.String(), want)
+	}
+}
+
+func TestFormatClock(t *testing.T) {
+	tests := map[time.Duration]string{
+		5 * time.Second:                           "0:05",
+		12*time.Minute + 3*time.Second:            "12:03",
+		time.Hour + 2*time.Minute + 9*time.Second: "1:02:09",
+	}
+	for elapsed, want := range tests {
+		if got := formatClock(elapsed); got != want {
+			t.Errorf("formatClock(%s) = %q, want %q", elapsed, got, want)
+		}
+	}
+}
@@ -0,0 +1,16 @@
+//go:build !unix
+
+package executor
+
+import "os/exec"
+
+// Process groups are unix only, elsewhere only the started process is signalled.
+func configureProcessGroup(cmd *exec.Cmd) {}
+
+func terminateProcessGroup(cmd *exec.Cmd) {
+	_ = cmd.Process.Kill()
+}
+
+func killProcessGroup(cmd *exec.Cmd) {
+	_ = cmd.Process.Kill()
+}
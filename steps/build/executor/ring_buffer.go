@@ -0,0 +1,40 @@
+package executor
+
+import "sync"
+
+// DefaultBufferLines is the number of output lines kept for failure analysis.
+const DefaultBufferLines = 2000
+
+// RingBuffer keeps the last lines written to it. It is safe for concurrent use.
+type RingBuffer struct {
+	mu    sync.Mutex
+	lines []string
+	next  int
+	full  bool
+}
+
+// NewRingBuffer returns a buffer keeping up to size lines.
+func NewRingBuffer(size int) *RingBuffer {
+	return &RingBuffer{lines: make([]string, max(size, 1))}
+}
+
+// Add records a line, dropping the oldest one when the buffer is full.
+func (b *RingBuffer) Add(line string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.lines[b.next] = line
+	b.next = (b.next + 1) % len(b.lines)
+	if b.next == 0 {
+		b.full = true
+	}
+}
+
+// Lines returns the kept lines, oldest first.
+func (b *RingBuffer) Lines() []string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if !b.full {
+		return append([]string{}, b.lines[:b.next]...)
+	}
+	return append(append([]string{}, b.lines[b.next:]...), b.lines[:b.next]...)
+}
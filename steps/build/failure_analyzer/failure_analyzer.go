@@ -0,0 +1,130 @@
+package failure_analyzer
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// maxCompileErrors is how many Dart compile errors are listed, the first ones usually cause the rest.
+const maxCompileErrors = 5
+
+// Finding is a probable cause of a build failure with a suggested fix.
+type Finding struct {
+	Cause string
+	Fix   string
+}
+
+func (f Finding) String() string {
+	return fmt.Sprintf("%s\n   Fix: %s", f.Cause, f.Fix)
+}
+
+// signature recognises a known failure from one line of output.
+type signature struct {
+	pattern *regexp.Regexp
+	finding func(match []string) Finding
+}
+
+var (
+	dartCompileError = regexp.MustCompile(`^\s*(\S+\.dart):(\d+):(\d+): Error: (.+)$`)
+	kotlinMetadata   = regexp.MustCompile(`compiled with an incompatible version of Kotlin.*binary version of its metadata is (\S+), expected version is (\S+?)\.?$`)
+	minSdkConflict   = regexp.MustCompile(`uses-sdk:minSdkVersion (\d+) cannot be smaller than version (\d+) declared in library \[([^\]]+)\]`)
+)
+
+var signatures = []signature{
+	{
+		pattern: kotlinMetadata,
+		finding: func(match []string) Finding {
+			return Finding{
+				Cause: fmt.Sprintf("Kotlin version mismatch: a dependency was compiled with Kotlin metadata %s, the project compiles with %s", match[1], match[2]),
+				Fix:   "Raise the Kotlin Gradle plugin version (org.jetbrains.kotlin.android) in android/settings.gradle to the version the dependency requires.",
+			}
+		},
+	},
+	{
+		pattern: regexp.MustCompile(`(?i)(requires Android Gradle plugin (\S+) or higher|Android Gradle plugin supports only Kotlin Gradle plugin version (\S+) and higher)`),
+		finding: func(match []string) Finding {
+			return Finding{
+				Cause: "Android Gradle plugin and Kotlin versions are incompatible: " + strings.TrimSpace(match[1]),
+				Fix:   "Align the com.android.application and org.jetbrains.kotlin.android plugin versions in android/settings.gradle, and the Gradle wrapper in android/gradle/wrapper/gradle-wrapper.properties.",
+			}
+		},
+	},
+	{
+		pattern: minSdkConflict,
+		finding: func(match []string) Finding {
+			return Finding{
+				Cause: fmt.Sprintf("minSdkVersion %s is lower than %s, required by %s", match[1], match[2], match[3]),
+				Fix:   fmt.Sprintf("Set minSdkVersion (minSdk) to at least %s in android/app/build.gradle.", match[2]),
+			}
+		},
+	},
+	{
+		pattern: regexp.MustCompile(`(ClassNotFoundException|Unable to find instrumentation info|cannot find symbol|Unresolved reference).*PatrolJUnitRunner|PatrolJUnitRunner.*(not found|does not exist)`),
+		finding: func(match []string) Finding {
+			return Finding{
+				Cause: "PatrolJUnitRunner is missing from the Android test setup",
+				Fix:   `Set testInstrumentationRunner "pl.leancode.patrol.PatrolJUnitRunner" in defaultConfig of android/app/build.gradle and add the MainActivityTest.java from the Patrol setup guide.`,
+			}
+		},
+	},
+	{
+		pattern: regexp.MustCompile(`(?i)(CocoaPods could not find compatible versions for pod "?([^"\s:]+)"?|out-of-date source repos|specs repository is too out-of-date)`),
+		finding: func(match []string) Finding {
+			cause := "CocoaPods specs are out of date"
+			if match[2] != "" {
+				cause = fmt.Sprintf("CocoaPods could not find a compatible version of %s", match[2])
+			}
+			return Finding{
+				Cause: cause,
+				Fix:   "Run `pod repo update` or `pod install --repo-update` in ios/, and check the platform version in ios/Podfile.",
+			}
+		},
+	},
+	{
+		pattern: regexp.MustCompile(`(?i)(No signing certificate "[^"]*" found|requires a provisioning profile|Signing for "[^"]+" requires a development team|No profiles for '[^']+' were found|Code ?Sign(ing)? error)`),
+		finding: func(match []string) Finding {
+			return Finding{
+				Cause: "iOS code signing failed: " + strings.TrimSpace(match[1]),
+				Fix:   "Install the certificate and provisioning profile before this step, for example with Certificate and profile installer, and select the development team for the Runner and RunnerUITests targets.",
+			}
+		},
+	},
+}
+
+// Analyze scans the build output for known failure signatures, in order of appearance.
+// Each kind of failure is reported once, Dart compile errors are listed with their file and line.
+func Analyze(lines []string) []Finding {
+	var findings []Finding
+	var compileErrors []string
+	seen := map[int]bool{}
+
+	for _, line := range lines {
+		if match := dartCompileError.FindStringSubmatch(line); match != nil {
+			compileError := fmt.Sprintf("%s:%s: %s", match[1], match[2], match[4])
+			if len(compileErrors) < maxCompileErrors && !slices.Contains(compileErrors, compileError) {
+				compileErrors = append(compileErrors, compileError)
+			}
+			continue
+		}
+
+		for index, signature := range signatures {
+			if seen[index] {
+				continue
+			}
+			if match := signature.pattern.FindStringSubmatch(line); match != nil {
+				seen[index] = true
+				findings = append(findings, signature.finding(match))
+			}
+		}
+	}
+
+	if len(compileErrors) > 0 {
+		findings = append([]Finding{{
+			Cause: "Dart compile errors:\n   - " + strings.Join(compileErrors, "\n   - "),
+			Fix:   "Fix the errors above, `flutter analyze` reports them without building.",
+		}}, findings...)
+	}
+	return findings
+}
@@ -0,0 +1,102 @@
+package failure_analyzer
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestAnalyze_Signatures(t *testing.T) {
+	tests := []struct {
+		name      string
+		line      string
+		wantCause string
+		wantFix   string
+	}{
+		{
+			name:      "kotlin",
+			line:      "e: /deps/classes.jar!/META-INF/core.kotlin_module: Module was compiled with an incompatible version of Kotlin. The binary version of its metadata is 1.9.0, expected version is 1.7.1.",
+			wantCause: "Kotlin metadata 1.9.0, the project compiles with 1.7.1",
+			wantFix:   "android/settings.gradle",
+		},
+		{
+			name:      "agp",
+			line:      "Dependency 'androidx.core:core:1.15.0' requires Android Gradle plugin 8.6.0 or higher.",
+			wantCause: "requires Android Gradle plugin 8.6.0 or higher",
+			wantFix:   "gradle-wrapper.properties",
+		},
+		{
+			name:      "min_sdk",
+			line:      "uses-sdk:minSdkVersion 19 cannot be smaller than version 21 declared in library [:patrol] /build/patrol/AndroidManifest.xml",
+			wantCause: "minSdkVersion 19 is lower than 21, required by :patrol",
+			wantFix:   "at least 21",
+		},
+		{
+			name:      "patrol_runner",
+			line:      "java.lang.ClassNotFoundException: pl.leancode.patrol.PatrolJUnitRunner",
+			wantCause: "PatrolJUnitRunner is missing",
+			wantFix:   "testInstrumentationRunner",
+		},
+		{
+			name:      "cocoapods",
+			line:      `[!] CocoaPods could not find compatible versions for pod "GoogleUtilities/Environment":`,
+			wantCause: "GoogleUtilities/Environment",
+			wantFix:   "pod install --repo-update",
+		},
+		{
+			name:      "signing",
+			line:      `error: Signing for "RunnerUITests" requires a development team. Select a development team in the Signing & Capabilities editor.`,
+			wantCause: "iOS code signing failed",
+			wantFix:   "provisioning profile",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			findings := Analyze([]string{"Running Gradle task 'assembleDebug'...", tt.line, "BUILD FAILED"})
+			if len(findings) != 1 {
+				t.Fatalf("expected one finding, got %+v", findings)
+			}
+			if !strings.Contains(findings[0].Cause, tt.wantCause) {
+				t.Errorf("Cause = %q, want it to contain %q", findings[0].Cause, tt.wantCause)
+			}
+			if !strings.Contains(findings[0].Fix, tt.wantFix) {
+				t.Errorf("Fix = %q, want it to contain %q", findings[0].Fix, tt.wantFix)
+			}
+		})
+	}
+}
+
+func TestAnalyze_DartCompileErrors(t *testing.T) {
+	// GIVEN compile errors, one of them repeated, and a signing error
+	findings := Analyze([]string{
+		"lib/main.dart:12:3: Error: Expected ';' after this.",
+		"patrol_test/login_test.dart:40:7: Error: The getter 'tapp' isn't defined for the class 'PatrolIntegrationTester'.",
+		"lib/main.dart:12:3: Error: Expected ';' after this.",
+		"Code Signing Error: No profiles for 'com.example.app' were found",
+	})
+
+	// THEN the compile errors come first, listed once with file and line
+	if len(findings) != 2 {
+		t.Fatalf("expected two findings, got %+v", findings)
+	}
+	want := "Dart compile errors:\n   - lib/main.dart:12: Expected ';' after this.\n   - patrol_test/login_test.dart:40: The getter 'tapp' isn't defined for the class 'PatrolIntegrationTester'."
+	if findings[0].Cause != want {
+		t.Errorf("Cause = %q, want %q", findings[0].Cause, want)
+	}
+	if !strings.HasPrefix(findings[1].Cause, "iOS code signing failed") {
+		t.Errorf("expected the signing error second, got %q", findings[1].Cause)
+	}
+}
+
+func TestAnalyze_ReportsEachSignatureOnce(t *testing.T) {
+	line := "java.lang.ClassNotFoundException: pl.leancode.patrol.PatrolJUnitRunner"
+	if findings := Analyze([]string{line, line}); len(findings) != 1 {
+		t.Errorf("expected one finding, got %d", len(findings))
+	}
+}
+
+func TestAnalyze_UnknownFailure(t *testing.T) {
+	if findings := Analyze([]string{"FAILURE: Build failed with an exception."}); len(findings) != 0 {
+		t.Errorf("expected no finding, got %+v", findings)
+	}
+}
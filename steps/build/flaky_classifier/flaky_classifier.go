@@ -0,0 +1,79 @@
+package flaky_classifier
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// CustomClassName names user patterns given without a name.
+const CustomClassName = "custom"
+
+// Class is a known kind of transient build failure, recognised by its output.
+type Class struct {
+	Name    string
+	Pattern *regexp.Regexp
+}
+
+// BuiltinClasses are the transient failures retried without any configuration.
+var BuiltinClasses = []Class{
+	// Only network failures, a misspelled or missing dependency fails again on every attempt
+	{Name: "gradle-dependency-resolution", Pattern: regexp.MustCompile(`(?i)received status code 5\d\d from server|could not (resolve|download|get|head) .*(timed out|connection reset|connection refused|remote host terminated the handshake|premature end of)`)},
+	{Name: "gradle-cache-lock", Pattern: regexp.MustCompile(`(?i)timeout waiting to lock`)},
+	{Name: "gradle-daemon-crash", Pattern: regexp.MustCompile(`(?i)gradle build daemon disappeared unexpectedly`)},
+	{Name: "cocoapods-cdn", Pattern: regexp.MustCompile(`(?i)CDN: trunk|cdn\.cocoapods\.org`)},
+	{Name: "network", Pattern: regexp.MustCompile(`(?i)connection reset by peer|read timed out|sockettimeoutexception|unknownhostexception|failed host lookup`)},
+}
+
+// Classifier matches build output against a list of transient failure classes, in order.
+type Classifier struct {
+	Classes []Class
+}
+
+// New returns a classifier with the built-in classes followed by the custom ones.
+func New(custom []Class) *Classifier {
+	classes := append([]Class{}, BuiltinClasses...)
+	return &Classifier{Classes: append(classes, custom...)}
+}
+
+// Classify returns the name of the first class matching a line of the output.
+func (c *Classifier) Classify(lines []string) (string, bool) {
+	for _, class := range c.Classes {
+		for _, line := range lines {
+			if class.Pattern.MatchString(line) {
+				return class.Name, true
+			}
+		}
+	}
+	return "", false
+}
+
+// ParseClasses reads one pattern per line, as `name=regex` or a bare regex named "custom".
+// Empty lines and lines starting with # are ignored.
+func ParseClasses(raw string) ([]Class, error) {
+	var classes []Class
+	for _, line := range strings.Split(raw, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		name, pattern := CustomClassName, line
+		if before, after, found := strings.Cut(line, "="); found && isClassName(before) {
+			name, pattern = before, after
+		}
+
+		compiled, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid retry pattern %q: %w", line, err)
+		}
+		classes = append(classes, Class{Name: name, Pattern: compiled})
+	}
+	return classes, nil
+}
+
+var className = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+func isClassName(name string) bool {
+	return className.MatchString(name)
+}
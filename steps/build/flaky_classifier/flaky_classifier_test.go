@@ -0,0 +1,71 @@
+package flaky_classifier
+
+import "testing"
+
+func TestClassify_Builtin(t *testing.T) {
+	classifier := New(nil)
+	tests := []struct {
+		line string
+		want string
+	}{
+		{line: "> Could not GET 'https://repo.maven.apache.org/maven2/androidx/core/core/1.13.1/core-1.13.1.pom'. Received status code 503 from server: Service Unavailable", want: "gradle-dependency-resolution"},
+		{line: "> Could not HEAD 'https://dl.google.com/dl/android/maven2/com/android/tools/build/gradle/8.3.0/gradle-8.3.0.pom'. Connect to dl.google.com:443 failed: Connection timed out", want: "gradle-dependency-resolution"},
+		{line: "Timeout waiting to lock build cache. It is currently in use by another Gradle instance.", want: "gradle-cache-lock"},
+		{line: "[!] CDN: trunk URL couldn't be downloaded: https://cdn.cocoapods.org/", want: "cocoapods-cdn"},
+		{line: "Gradle build daemon disappeared unexpectedly (it may have been killed or may have crashed)", want: "gradle-daemon-crash"},
+		{line: "java.net.SocketTimeoutException: Read timed out", want: "network"},
+	}
+
+	for _, tt := range tests {
+		got, ok := classifier.Classify([]string{"FAILURE: Build failed with an exception.", tt.line})
+		if !ok || got != tt.want {
+			t.Errorf("Classify(%q) = (%q, %v), want %q", tt.line, got, ok, tt.want)
+		}
+	}
+}
+
+func TestClassify_UnknownFailure(t *testing.T) {
+	classifier := New(nil)
+	if got, ok := classifier.Classify([]string{"lib/main.dart:12:3: Error: Expected ';' after this."}); ok {
+		t.Errorf("expected no class for a compile error, got %q", got)
+	}
+}
+
+func TestClassify_PermanentResolutionFailure(t *testing.T) {
+	classifier := New(nil)
+	// GIVEN a misspelled dependency, reported the same way on every attempt
+	lines := []string{
+		"> Could not resolve all files for configuration ':app:debugRuntimeClasspath'.",
+		"   > Could not find com.squareup.okhttp3:okhttpp:4.12.0.",
+		"> Could not GET 'https://repo.example.com/private/lib-1.0.pom'. Received status code 401 from server: Unauthorized",
+	}
+
+	if got, ok := classifier.Classify(lines); ok {
+		t.Errorf("expected a permanent resolution failure not to be retried, got %q", got)
+	}
+}
+
+func TestParseClasses(t *testing.T) {
+	// GIVEN named, bare, commented and empty lines
+	classes, err := ParseClasses("# retried\nsimulator=Unable to boot the Simulator\n\nxcodebuild: error: .*timed out\n")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	// THEN named and bare patterns are parsed in order
+	if len(classes) != 2 || classes[0].Name != "simulator" || classes[1].Name != CustomClassName {
+		t.Fatalf("unexpected classes %+v", classes)
+	}
+
+	// AND they are matched after the built-in classes
+	got, ok := New(classes).Classify([]string{"Unable to boot the Simulator."})
+	if !ok || got != "simulator" {
+		t.Errorf("Classify() = (%q, %v), want simulator", got, ok)
+	}
+}
+
+func TestParseClasses_InvalidPattern(t *testing.T) {
+	if _, err := ParseClasses("broken=([a-z"); err == nil {
+		t.Error("expected an error for an invalid regex")
+	}
+}
@@ -0,0 +1,89 @@
+package builder
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	constants "patrol_install/steps/build/constants"
+	"patrol_install/steps/build/executor"
+)
+
+// OptionsFromEnv returns the executor options configured by BUILD_TIMEOUT, NO_OUTPUT_TIMEOUT and OUTPUT_ELAPSED_PREFIX.
+// The platform prefix depends on the command, see platformPrefixFromEnv.
+func OptionsFromEnv() (executor.Options, error) {
+	timeout, err := timeoutFromEnv(constants.BuildTimeout)
+	if err != nil {
+		return executor.Options{}, err
+	}
+	noOutputTimeout, err := timeoutFromEnv(constants.NoOutputTimeout)
+	if err != nil {
+		return executor.Options{}, err
+	}
+	elapsed, err := boolFromEnv(constants.OutputElapsedPrefix)
+	if err != nil {
+		return executor.Options{}, err
+	}
+	return executor.Options{Timeout: timeout, NoOutputTimeout: noOutputTimeout, Elapsed: elapsed}, nil
+}
+
+// platformPrefixFromEnv reads OUTPUT_PLATFORM_PREFIX.
+func platformPrefixFromEnv() (bool, error) {
+	return boolFromEnv(constants.OutputPlatformPrefix)
+}
+
+// commandPlatform returns "android" or "ios" for a `patrol build` command, empty for other commands.
+func commandPlatform(command string) string {
+	fields := strings.Fields(command)
+	if len(fields) < 3 || fields[0] != "patrol" || fields[1] != "build" {
+		return ""
+	}
+	switch fields[2] {
+	case constants.PlatformAndroid, constants.PlatformIOS:
+		return fields[2]
+	}
+	return ""
+}
+
+// platformPrefix returns "[android]" or "[ios]" for a `patrol build` command, empty for other commands.
+func platformPrefix(command string) string {
+	if platform := commandPlatform(command); platform != "" {
+		return "[" + platform + "]"
+	}
+	return ""
+}
+
+// ContinueOnPlatformFailureFromEnv reads CONTINUE_ON_PLATFORM_FAILURE, the best-effort mode where
+// a failing platform doesn't stop the build and export of the other one.
+func ContinueOnPlatformFailureFromEnv() (bool, error) {
+	return boolFromEnv(constants.ContinueOnPlatformFailure)
+}
+
+func boolFromEnv(key string) (bool, error) {
+	switch strings.ToLower(strings.TrimSpace(os.Getenv(key))) {
+	case "", "false":
+		return false, nil
+	case "true":
+		return true, nil
+	default:
+		return false, fmt.Errorf("invalid value for %s: expected 'true' or 'false'", key)
+	}
+}
+
+// timeoutFromEnv parses a number of minutes or a Go duration such as "1h30m". Empty and zero disable the timeout.
+func timeoutFromEnv(key string) (time.Duration, error) {
+	raw := strings.TrimSpace(os.Getenv(key))
+	if raw == "" {
+		return 0, nil
+	}
+	if minutes, err := strconv.Atoi(raw); err == nil && minutes >= 0 {
+		return time.Duration(minutes) * time.Minute, nil
+	}
+	timeout, err := time.ParseDuration(raw)
+	if err != nil || timeout < 0 {
+		return 0, fmt.Errorf("invalid %s %q: expected minutes or a duration such as 1h30m", key, raw)
+	}
+	return timeout, nil
+}
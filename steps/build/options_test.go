@@ -0,0 +1,53 @@
+package builder
+
+import (
+	"testing"
+	"time"
+
+	constants "patrol_install/steps/build/constants"
+)
+
+func TestOptionsFromEnv(t *testing.T) {
+	tests := []struct {
+		name         string
+		timeout      string
+		noOutput     string
+		wantTimeout  time.Duration
+		wantNoOutput time.Duration
+		wantErr      bool
+	}{
+		{name: "disabled", timeout: "", noOutput: ""},
+		{name: "minutes", timeout: "45", noOutput: "10", wantTimeout: 45 * time.Minute, wantNoOutput: 10 * time.Minute},
+		{name: "durations", timeout: "1h30m", noOutput: "90s", wantTimeout: 90 * time.Minute, wantNoOutput: 90 * time.Second},
+		{name: "invalid", timeout: "soon", wantErr: true},
+		{name: "negative", noOutput: "-5m", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(constants.BuildTimeout, tt.timeout)
+			t.Setenv(constants.NoOutputTimeout, tt.noOutput)
+
+			got, err := OptionsFromEnv()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("OptionsFromEnv() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got.Timeout != tt.wantTimeout || got.NoOutputTimeout != tt.wantNoOutput {
+				t.Errorf("OptionsFromEnv() = %+v", got)
+			}
+		})
+	}
+}
+
+func TestPlatformPrefix(t *testing.T) {
+	tests := map[string]string{
+		"patrol build android --release --target patrol_test": "[android]",
+		"patrol build ios --debug --simulator":                "[ios]",
+		"flutter pub get":                                     "",
+	}
+	for command, want := range tests {
+		if got := platformPrefix(command); got != want {
+			t.Errorf("platformPrefix(%q) = %q, want %q", command, got, want)
+		}
+	}
+}
@@ -0,0 +1,132 @@
+package builder
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	constants "patrol_install/steps/build/constants"
+	"patrol_install/steps/build/executor"
+	"patrol_install/utils/print"
+)
+
+type platformLog struct {
+	path   string
+	envKey string
+}
+
+// platformLogs are the per-platform logs written by parallel builds.
+var platformLogs = map[string]platformLog{
+	constants.PlatformAndroid: {path: AndroidLogPath, envKey: AndroidLogPathEnvKey},
+	constants.PlatformIOS:     {path: IOSLogPath, envKey: IOSLogPathEnvKey},
+}
+
+// platformBuild is the outcome of one platform build run in parallel.
+type platformBuild struct {
+	command  string
+	prefix   string
+	result   *executor.Result
+	duration time.Duration
+	err      error
+}
+
+// runParallel runs the platform builds concurrently, each with prefixed output and its own log.
+// Unless continueOnFailure is set, the first failure cancels the other builds. Both results are
+// reported once every build stopped.
+func runParallel(commands []string, options executor.Options, policy *RetryPolicy, continueOnFailure bool) error {
+	print.Action(fmt.Sprintf("Building %d platforms in parallel", len(commands)))
+
+	cancel := make(chan struct{})
+	var cancelOnce sync.Once
+	builds := make([]platformBuild, len(commands))
+
+	var wg sync.WaitGroup
+	for i, command := range commands {
+		commandOptions := options
+		commandOptions.Prefix = platformPrefix(command)
+		commandOptions.Cancel = cancel
+		log := openPlatformLog(command)
+		if log != nil {
+			commandOptions.Log = teeLog(options.Log, log)
+		}
+
+		wg.Add(1)
+		go func(i int, command string, options executor.Options, log io.Closer) {
+			defer wg.Done()
+			if log != nil {
+				// Closed once this platform is done, the other one may still be building
+				defer log.Close()
+			}
+			print.Action(fmt.Sprintf("%s Executing build command: %s", options.Prefix, command))
+
+			started := time.Now()
+			result, attempts, err := runWithRetry(command, options, policy)
+			recordCommand(command, attempts, err)
+			builds[i] = platformBuild{command: command, prefix: options.Prefix, result: result, duration: time.Since(started), err: err}
+
+			if err != nil && !continueOnFailure && !errors.Is(err, executor.ErrCanceled) {
+				cancelOnce.Do(func() {
+					print.Warning(fmt.Sprintf("%s build failed, canceling the other platform builds", options.Prefix))
+					close(cancel)
+				})
+			}
+		}(i, command, commandOptions, log)
+	}
+	wg.Wait()
+
+	return reportParallel(builds)
+}
+
+// reportParallel prints the failures, then a summary of every build, and joins the errors.
+func reportParallel(builds []platformBuild) error {
+	var errs []error
+	for _, build := range builds {
+		if build.err != nil && !errors.Is(build.err, executor.ErrCanceled) {
+			print.Error(fmt.Sprintf("❌ %s Command failed: %s", build.prefix, build.err))
+			printFailure(build.result)
+		}
+	}
+
+	print.Vanilla("Parallel build results:")
+	for _, build := range builds {
+		elapsed := build.duration.Round(time.Second)
+		switch {
+		case build.err == nil:
+			print.Success(fmt.Sprintf("%s ✅ succeeded in %s", build.prefix, elapsed))
+		case errors.Is(build.err, executor.ErrCanceled):
+			print.Warning(fmt.Sprintf("%s ⏹️ canceled after %s", build.prefix, elapsed))
+			errs = append(errs, &PlatformError{Platform: commandPlatform(build.command), Err: fmt.Errorf("%s canceled", build.prefix)})
+		default:
+			print.Error(fmt.Sprintf("%s ❌ failed after %s: %s", build.prefix, elapsed, build.err))
+			errs = append(errs, &PlatformError{
+				Platform: commandPlatform(build.command),
+				Err:      fmt.Errorf("build aborted: failed to execute '%s': %w", build.command, build.err),
+			})
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// openPlatformLog opens the log of the command's platform, nil when it can't be written.
+func openPlatformLog(command string) io.WriteCloser {
+	platformLog, ok := platformLogs[commandPlatform(command)]
+	if !ok {
+		return nil
+	}
+	log, err := openBuildLog(platformLog.path, platformLog.envKey)
+	if err != nil {
+		print.Warning("⚠️ " + err.Error())
+		return nil
+	}
+	return log
+}
+
+// teeLog writes to the build log, when it is open, and the platform log.
+func teeLog(buildLog, platformLog io.Writer) io.Writer {
+	if buildLog == nil {
+		return platformLog
+	}
+	return io.MultiWriter(buildLog, platformLog)
+}
@@ -0,0 +1,88 @@
+package builder
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"patrol_install/steps/build/executor"
+	flaky_classifier "patrol_install/steps/build/flaky_classifier"
+	export_artifacts_utils "patrol_install/steps/export_artifacts/utils"
+)
+
+// fakePatrol puts a `patrol` script on PATH whose android build fails and ios build takes a while,
+// and runs the test in a temporary project.
+func fakePatrol(t *testing.T, iosSeconds string) {
+	t.Helper()
+	bin := t.TempDir()
+	script := "#!/bin/sh\nif [ \"$2\" = android ]; then echo 'Gradle failed'; exit 1; fi\necho 'Xcode build'; sleep " + iosSeconds + "; echo 'Xcode done'\n"
+	if err := os.WriteFile(filepath.Join(bin, "patrol"), []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+	t.Chdir(t.TempDir())
+
+	spy := &exporterSpy{exported: map[string]string{}}
+	export_artifacts_utils.SetEnvExporter(spy)
+	t.Cleanup(func() {
+		export_artifacts_utils.SetEnvExporter(nil)
+	})
+}
+
+func parallelBuild(continueOnFailure bool) error {
+	commands := []string{"patrol build android --release", "patrol build ios --release"}
+	policy := &RetryPolicy{Classifier: flaky_classifier.New(nil)}
+	return runParallel(commands, executor.Options{}, policy, continueOnFailure)
+}
+
+func TestRunParallel_CancelsOtherPlatform(t *testing.T) {
+	fakePatrol(t, "30")
+	started := time.Now()
+
+	// WHEN android fails while ios is building
+	err := parallelBuild(false)
+
+	// THEN ios is canceled and both results are reported
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	if elapsed := time.Since(started); elapsed > 15*time.Second {
+		t.Errorf("expected ios to be canceled, took %s", elapsed)
+	}
+	if got := err.Error(); !strings.Contains(got, "[ios] canceled") || !strings.Contains(got, "patrol build android") {
+		t.Errorf("unexpected error %q", got)
+	}
+	if got := FailedPlatforms(err); !slices.Equal(got, []string{"android", "ios"}) {
+		t.Errorf("FailedPlatforms() = %v, want both platforms", got)
+	}
+}
+
+func TestRunParallel_ContinuesOnPlatformFailure(t *testing.T) {
+	fakePatrol(t, "0.2")
+
+	// WHEN android fails and failures don't cancel
+	err := parallelBuild(true)
+
+	// THEN ios completes and only android is reported as failed
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	if strings.Contains(err.Error(), "canceled") {
+		t.Errorf("expected ios to complete, got %q", err)
+	}
+	if got := FailedPlatforms(err); !slices.Equal(got, []string{"android"}) {
+		t.Errorf("FailedPlatforms() = %v, want only android", got)
+	}
+
+	// AND each platform has its own log
+	data, err := os.ReadFile(IOSLogPath)
+	if err != nil {
+		t.Fatalf("expected the ios log, got %v", err)
+	}
+	if string(data) != "[ios] Xcode build\n[ios] Xcode done\n" {
+		t.Errorf("ios log = %q", data)
+	}
+}
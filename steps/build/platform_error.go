@@ -0,0 +1,43 @@
+package builder
+
+import "slices"
+
+// PlatformError is a build command that failed or was canceled, along with the platform it builds.
+// It lets callers tell which platforms of a build invocation have no fresh outputs.
+type PlatformError struct {
+	// Platform is android or ios, empty for a command that isn't a `patrol build <platform>`.
+	Platform string
+	Err      error
+}
+
+func (e *PlatformError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *PlatformError) Unwrap() error {
+	return e.Err
+}
+
+// FailedPlatforms returns the platforms of the PlatformErrors found in err, joined errors included,
+// in order and without duplicates. It is empty when err is nil or names no platform.
+func FailedPlatforms(err error) []string {
+	var platforms []string
+	var walk func(err error)
+	walk = func(err error) {
+		switch wrapped := err.(type) {
+		case nil:
+		case *PlatformError:
+			if wrapped.Platform != "" && !slices.Contains(platforms, wrapped.Platform) {
+				platforms = append(platforms, wrapped.Platform)
+			}
+		case interface{ Unwrap() []error }:
+			for _, inner := range wrapped.Unwrap() {
+				walk(inner)
+			}
+		case interface{ Unwrap() error }:
+			walk(wrapped.Unwrap())
+		}
+	}
+	walk(err)
+	return platforms
+}
@@ -0,0 +1,33 @@
+package builder
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+)
+
+func TestFailedPlatforms(t *testing.T) {
+	android := &PlatformError{Platform: "android", Err: errors.New("gradle failed")}
+	ios := &PlatformError{Platform: "ios", Err: errors.New("xcodebuild failed")}
+
+	tests := []struct {
+		name string
+		err  error
+		want []string
+	}{
+		{name: "nil", err: nil, want: nil},
+		{name: "no_platform", err: errors.New("invalid build timeouts"), want: nil},
+		{name: "single", err: android, want: []string{"android"}},
+		{name: "wrapped", err: fmt.Errorf("shard 1: %w", ios), want: []string{"ios"}},
+		{name: "joined", err: errors.Join(android, fmt.Errorf("retry: %w", ios), android), want: []string{"android", "ios"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := FailedPlatforms(tt.err); !slices.Equal(got, tt.want) {
+				t.Errorf("FailedPlatforms() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
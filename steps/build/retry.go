@@ -0,0 +1,83 @@
+package builder
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	build_report "patrol_install/steps/build/build_report"
+	constants "patrol_install/steps/build/constants"
+	"patrol_install/steps/build/executor"
+	flaky_classifier "patrol_install/steps/build/flaky_classifier"
+	"patrol_install/utils/print"
+)
+
+const (
+	defaultBuildRetries = 0
+	buildRetryDelay     = 10 * time.Second
+)
+
+// sleep is swapped in tests to avoid waiting between attempts.
+var sleep = time.Sleep
+
+// RetryPolicy is how often build commands failing with a known transient error are retried.
+type RetryPolicy struct {
+	Retries    int
+	Classifier *flaky_classifier.Classifier
+}
+
+// RetryPolicyFromEnv reads BUILD_RETRIES and the custom BUILD_RETRY_PATTERNS.
+func RetryPolicyFromEnv() (*RetryPolicy, error) {
+	policy := &RetryPolicy{Retries: defaultBuildRetries}
+
+	if raw := strings.TrimSpace(os.Getenv(constants.BuildRetries)); raw != "" {
+		retries, err := strconv.Atoi(raw)
+		if err != nil || retries < 0 {
+			return nil, fmt.Errorf("invalid %s %q: expected a non-negative number", constants.BuildRetries, raw)
+		}
+		policy.Retries = retries
+	}
+
+	custom, err := flaky_classifier.ParseClasses(os.Getenv(constants.BuildRetryPatterns))
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", constants.BuildRetryPatterns, err)
+	}
+	policy.Classifier = flaky_classifier.New(custom)
+	return policy, nil
+}
+
+// runWithRetry executes the command, retrying it while the failure matches a known transient class.
+// Timeouts and canceled builds are never retried. The result of the last attempt is returned with all attempts, for the build report.
+func runWithRetry(command string, options executor.Options, policy *RetryPolicy) (*executor.Result, []build_report.Attempt, error) {
+	var attempts []build_report.Attempt
+	for number := 1; ; number++ {
+		result, err := executor.Execute(command, options)
+		attempt := build_report.Attempt{Number: number, DurationSeconds: result.Duration.Seconds()}
+		if err == nil {
+			return result, append(attempts, attempt), nil
+		}
+		attempt.Error = err.Error()
+
+		var timeoutErr *executor.TimeoutError
+		class, transient := "", false
+		if !errors.As(err, &timeoutErr) && !errors.Is(err, executor.ErrCanceled) {
+			class, transient = policy.Classifier.Classify(result.Output)
+		}
+		attempt.FlakyClass = class
+		attempts = append(attempts, attempt)
+
+		if !transient || number > policy.Retries {
+			if transient {
+				print.Warning(fmt.Sprintf("Build failed with the known transient error %q, no retries left", class))
+			}
+			return result, attempts, err
+		}
+
+		print.Warning(fmt.Sprintf("Build attempt %d/%d failed with the known transient error %q. Retrying in %s...",
+			number, policy.Retries+1, class, buildRetryDelay))
+		sleep(buildRetryDelay)
+	}
+}
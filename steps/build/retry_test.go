@@ -0,0 +1,93 @@
+package builder
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	constants "patrol_install/steps/build/constants"
+	"patrol_install/steps/build/executor"
+	flaky_classifier "patrol_install/steps/build/flaky_classifier"
+)
+
+func noSleep(t *testing.T) {
+	t.Helper()
+	previous := sleep
+	sleep = func(time.Duration) {}
+	t.Cleanup(func() {
+		sleep = previous
+	})
+}
+
+// failOnce fails with the given output on the first run and succeeds afterwards.
+func failOnce(t *testing.T, output string) string {
+	marker := filepath.Join(t.TempDir(), "failed")
+	return fmt.Sprintf("if [ -f %s ]; then echo built; else touch %s; echo '%s'; exit 1; fi", marker, marker, output)
+}
+
+func TestRunWithRetry_RetriesTransientFailure(t *testing.T) {
+	noSleep(t)
+	policy := &RetryPolicy{Retries: 1, Classifier: flaky_classifier.New(nil)}
+
+	// GIVEN a build failing once on a Gradle cache lock
+	command := failOnce(t, "Timeout waiting to lock build cache")
+
+	// WHEN running it with one retry
+	_, attempts, err := runWithRetry(command, executor.Options{}, policy)
+
+	// THEN the second attempt succeeds and the first records the class
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(attempts) != 2 || attempts[0].FlakyClass != "gradle-cache-lock" || attempts[1].Error != "" {
+		t.Errorf("unexpected attempts %+v", attempts)
+	}
+}
+
+func TestRunWithRetry_DoesNotRetryUnknownFailure(t *testing.T) {
+	noSleep(t)
+	policy := &RetryPolicy{Retries: 3, Classifier: flaky_classifier.New(nil)}
+
+	_, attempts, err := runWithRetry(failOnce(t, "Error: Expected a value of type int"), executor.Options{}, policy)
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	if len(attempts) != 1 || attempts[0].FlakyClass != "" {
+		t.Errorf("unexpected attempts %+v", attempts)
+	}
+}
+
+func TestRunWithRetry_StopsWithoutRetriesLeft(t *testing.T) {
+	noSleep(t)
+	policy := &RetryPolicy{Retries: 0, Classifier: flaky_classifier.New(nil)}
+
+	_, attempts, err := runWithRetry(failOnce(t, "Connection reset by peer"), executor.Options{}, policy)
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	if len(attempts) != 1 || attempts[0].FlakyClass != "network" {
+		t.Errorf("unexpected attempts %+v", attempts)
+	}
+}
+
+func TestRetryPolicyFromEnv(t *testing.T) {
+	t.Setenv(constants.BuildRetries, "")
+	t.Setenv(constants.BuildRetryPatterns, "simulator=Unable to boot")
+
+	policy, err := RetryPolicyFromEnv()
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if policy.Retries != 0 {
+		t.Errorf("Retries = %d, want retries disabled by default", policy.Retries)
+	}
+	if class, ok := policy.Classifier.Classify([]string{"Unable to boot device"}); !ok || class != "simulator" {
+		t.Errorf("expected the custom pattern, got (%q, %v)", class, ok)
+	}
+
+	t.Setenv(constants.BuildRetries, "-1")
+	if _, err := RetryPolicyFromEnv(); err == nil {
+		t.Error("expected an error for negative retries")
+	}
+}
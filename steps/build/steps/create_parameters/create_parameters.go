@@ -5,8 +5,12 @@ import (
 
 	constants "patrol_install/steps/build/constants"
 	bp "patrol_install/steps/build/models/build_parameters"
+	verify_target "patrol_install/steps/build/steps/verify_target"
 )
 
+// projectDir is the Flutter project the step runs in.
+const projectDir = "."
+
 func BuildParametersFromEnv() (*bp.BuildParameters, error) {
 	envMap := map[string]string{
 		"platform":     os.Getenv(constants.Platform),
@@ -17,6 +21,16 @@ func BuildParametersFromEnv() (*bp.BuildParameters, error) {
 		"verbose":      os.Getenv(constants.IsVerboseMode),
 	}
 
+	params, err := bp.NewBuildParameters(envMap)
+	if err != nil {
+		return nil, err
+	}
+
+	// Catch target typos before `patrol build` spends minutes compiling
+	if err := verify_target.VerifyTarget(projectDir, params.Target); err != nil {
+		return nil, err
+	}
+
 	// Final build
-	return bp.NewBuildParameters(envMap)
+	return params, nil
 }
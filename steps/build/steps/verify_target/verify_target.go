@@ -0,0 +1,143 @@
+package verify_target
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	constants "patrol_install/steps/build/constants"
+	"patrol_install/utils/pubspec"
+	"patrol_install/utils/suggest"
+)
+
+// DefaultTestDirectory is the Patrol CLI default when pubspec.yaml doesn't set patrol.test_directory.
+const DefaultTestDirectory = "patrol_test"
+
+const maxSuggestions = 3
+
+var patrolTestDeclaration = regexp.MustCompile(`\bpatrol(?:Widget)?Test\s*\(`)
+
+// Directories that never contain Patrol tests, skipped when looking for suggestions.
+var skippedDirs = map[string]bool{
+	"build":        true,
+	"android":      true,
+	"ios":          true,
+	"node_modules": true,
+	"Pods":         true,
+}
+
+// TestDirectory returns the patrol.test_directory configured in the project's pubspec.yaml, or the default.
+func TestDirectory(projectDir string) string {
+	config, err := pubspec.ReadPubspec(filepath.Join(projectDir, pubspec.FileName))
+	if err != nil || config.Patrol == nil || strings.TrimSpace(config.Patrol.TestDirectory) == "" {
+		return DefaultTestDirectory
+	}
+	return filepath.Clean(strings.TrimSpace(config.Patrol.TestDirectory))
+}
+
+// VerifyTarget checks that the target, relative to projectDir, exists, lives under the test
+// directory and declares at least one patrolTest or patrolWidgetTest.
+func VerifyTarget(projectDir, target string) error {
+	testDirectory := TestDirectory(projectDir)
+	relative := relativeTarget(projectDir, target)
+
+	info, err := os.Stat(filepath.Join(projectDir, relative))
+	if err != nil {
+		message := fmt.Sprintf("%s %q does not exist in the project", constants.TestTargetDirectory, target)
+		if suggestions := suggest.Closest(filepath.ToSlash(relative), candidates(projectDir), maxSuggestions); len(suggestions) > 0 {
+			message += ". Did you mean: " + strings.Join(suggestions, ", ") + "?"
+		}
+		return fmt.Errorf("%s", message)
+	}
+
+	if !isWithin(relative, testDirectory) {
+		return fmt.Errorf("%s %q is outside the test directory %q; move the tests there or set `test_directory` in the `patrol:` section of pubspec.yaml",
+			constants.TestTargetDirectory, target, testDirectory)
+	}
+
+	found, err := declaresPatrolTest(filepath.Join(projectDir, relative), info.IsDir())
+	if err != nil {
+		return fmt.Errorf("failed to read %s %q: %w", constants.TestTargetDirectory, target, err)
+	}
+	if !found {
+		return fmt.Errorf("%s %q does not declare any patrolTest( or patrolWidgetTest(", constants.TestTargetDirectory, target)
+	}
+	return nil
+}
+
+// relativeTarget returns the target relative to projectDir, keeping it as is when it can't be made relative.
+func relativeTarget(projectDir, target string) string {
+	cleaned := filepath.Clean(strings.TrimSpace(target))
+	if !filepath.IsAbs(cleaned) {
+		return cleaned
+	}
+	absProject, err := filepath.Abs(projectDir)
+	if err != nil {
+		return cleaned
+	}
+	relative, err := filepath.Rel(absProject, cleaned)
+	if err != nil {
+		return cleaned
+	}
+	return relative
+}
+
+func isWithin(relative, directory string) bool {
+	return relative == directory || strings.HasPrefix(relative, directory+string(filepath.Separator))
+}
+
+func declaresPatrolTest(path string, isDir bool) (bool, error) {
+	if !isDir {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return false, err
+		}
+		return patrolTestDeclaration.Match(data), nil
+	}
+
+	found := false
+	err := filepath.WalkDir(path, func(file string, entry fs.DirEntry, err error) error {
+		if err != nil || found {
+			return err
+		}
+		if entry.IsDir() || filepath.Ext(file) != ".dart" {
+			return nil
+		}
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		found = patrolTestDeclaration.Match(data)
+		return nil
+	})
+	return found, err
+}
+
+// candidates lists the Dart files and directories of the project, relative to projectDir, as suggestions.
+func candidates(projectDir string) []string {
+	var paths []string
+	_ = filepath.WalkDir(projectDir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		relative, relErr := filepath.Rel(projectDir, path)
+		if relErr != nil || relative == "." {
+			return nil
+		}
+		if entry.IsDir() {
+			if skippedDirs[entry.Name()] || strings.HasPrefix(entry.Name(), ".") {
+				return filepath.SkipDir
+			}
+			paths = append(paths, filepath.ToSlash(relative))
+			return nil
+		}
+		if filepath.Ext(path) == ".dart" {
+			paths = append(paths, filepath.ToSlash(relative))
+		}
+		return nil
+	})
+	return paths
+}
@@ -0,0 +1,95 @@
+package verify_target
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const patrolTestFile = `void main() {
+  patrolTest('logs in', ($) async {});
+}
+`
+
+func writeProject(t *testing.T, files map[string]string) string {
+	t.Helper()
+	root := t.TempDir()
+	for path, content := range files {
+		full := filepath.Join(root, path)
+		if err := os.MkdirAll(filepath.Dir(full), 0755); err != nil {
+			t.Fatalf("failed to create dir: %v", err)
+		}
+		if err := os.WriteFile(full, []byte(content), 0644); err != nil {
+			t.Fatalf("failed to write %s: %v", path, err)
+		}
+	}
+	return root
+}
+
+func TestVerifyTarget_Valid(t *testing.T) {
+	root := writeProject(t, map[string]string{
+		"pubspec.yaml":                     "name: example\n",
+		"patrol_test/login_test.dart":      patrolTestFile,
+		"patrol_test/flows/cart_test.dart": "void main() {\n  patrolWidgetTest('cart', ($) async {});\n}\n",
+	})
+
+	for _, target := range []string{"patrol_test/login_test.dart", "patrol_test", "patrol_test/flows", filepath.Join(root, "patrol_test")} {
+		if err := VerifyTarget(root, target); err != nil {
+			t.Errorf("VerifyTarget(%q) unexpected error: %v", target, err)
+		}
+	}
+}
+
+func TestVerifyTarget_MissingSuggestsCloseMatches(t *testing.T) {
+	root := writeProject(t, map[string]string{
+		"patrol_test/login_test.dart":  patrolTestFile,
+		"patrol_test/logout_test.dart": patrolTestFile,
+	})
+
+	err := VerifyTarget(root, "patrol_test/logn_test.dart")
+	if err == nil {
+		t.Fatal("expected error for missing target")
+	}
+	if !strings.Contains(err.Error(), "Did you mean: patrol_test/login_test.dart") {
+		t.Errorf("expected a suggestion, got %v", err)
+	}
+
+	err = VerifyTarget(root, "patrol_tests")
+	if err == nil || !strings.Contains(err.Error(), "Did you mean: patrol_test") {
+		t.Errorf("expected the test directory to be suggested, got %v", err)
+	}
+}
+
+func TestVerifyTarget_OutsideTestDirectory(t *testing.T) {
+	root := writeProject(t, map[string]string{
+		"integration_test/app_test.dart": patrolTestFile,
+	})
+
+	err := VerifyTarget(root, "integration_test/app_test.dart")
+	if err == nil || !strings.Contains(err.Error(), `outside the test directory "patrol_test"`) {
+		t.Fatalf("expected an outside test directory error, got %v", err)
+	}
+}
+
+func TestVerifyTarget_ConfiguredTestDirectory(t *testing.T) {
+	root := writeProject(t, map[string]string{
+		"pubspec.yaml":                   "name: example\npatrol:\n  test_directory: integration_test\n",
+		"integration_test/app_test.dart": patrolTestFile,
+	})
+
+	if err := VerifyTarget(root, "integration_test/app_test.dart"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestVerifyTarget_NoPatrolTests(t *testing.T) {
+	root := writeProject(t, map[string]string{
+		"patrol_test/helpers.dart": "void login() {}\n",
+	})
+
+	err := VerifyTarget(root, "patrol_test/helpers.dart")
+	if err == nil || !strings.Contains(err.Error(), "does not declare any patrolTest(") {
+		t.Fatalf("expected a missing declaration error, got %v", err)
+	}
+}
@@ -0,0 +1,156 @@
+package build_cache
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	build "patrol_install/steps/build"
+	build_constants "patrol_install/steps/build/constants"
+	export_artifacts_utils "patrol_install/steps/export_artifacts/utils"
+	"patrol_install/utils/print"
+)
+
+const (
+	defaultCacheDirName = ".patrol_build_cache"
+
+	// BitriseCacheIncludePathsEnvKey lists the paths saved by the Bitrise cache steps.
+	BitriseCacheIncludePathsEnvKey = "BITRISE_CACHE_INCLUDE_PATHS"
+)
+
+type BuildCache interface {
+	// BuildInputs returns the build settings and tool versions included in the fingerprint.
+	BuildInputs() (map[string]string, error)
+}
+
+// Config is the cache requested with BUILD_CACHE and BUILD_CACHE_DIR.
+type Config struct {
+	Enabled bool
+	Dir     string
+}
+
+type RunParams struct {
+	Runner     BuildCache
+	Config     *Config
+	ProjectDir string
+	// Build builds and exports the artifacts, it runs on a cache miss.
+	Build func() error
+}
+
+// ConfigFromEnv reads BUILD_CACHE, defaulting to disabled, and BUILD_CACHE_DIR.
+func ConfigFromEnv() (*Config, error) {
+	config := &Config{}
+
+	switch value := strings.ToLower(strings.TrimSpace(os.Getenv(build_constants.BuildCache))); value {
+	case "", "false":
+	case "true":
+		config.Enabled = true
+	default:
+		return nil, fmt.Errorf("invalid value for %s: expected 'true' or 'false'", build_constants.BuildCache)
+	}
+
+	config.Dir = strings.TrimSpace(os.Getenv(build_constants.BuildCacheDir))
+	if config.Dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			home = os.TempDir()
+		}
+		config.Dir = filepath.Join(home, defaultCacheDirName)
+	}
+	return config, nil
+}
+
+// Run exports the artifacts of a previous build with the same fingerprint, or builds them and stores
+// them for the next run. The cache is best effort: when it can't be used the build runs normally.
+func Run(params RunParams) error {
+	if params.Config == nil || !params.Config.Enabled {
+		return params.Build()
+	}
+
+	print.StepInitiated("--- Checking build cache ---")
+
+	fingerprint, err := fingerprintFor(params)
+	if err != nil {
+		print.Warning("⚠️ Build cache disabled for this run: " + err.Error())
+		return params.Build()
+	}
+	print.Vanilla("Build fingerprint: " + fingerprint)
+
+	outputs, hit, err := Restore(params.Config.Dir, fingerprint, params.ProjectDir)
+	if err != nil {
+		print.Warning("⚠️ Could not restore the cached build, rebuilding: " + err.Error())
+	}
+	if hit && err == nil {
+		if err := exportOutputs(outputs); err != nil {
+			print.Error("❌ " + err.Error())
+			return err
+		}
+		print.StepCompleted("✅ Inputs are unchanged, exported the artifacts of the cached build\n")
+		return nil
+	}
+	print.StepCompleted("Build cache miss, building\n")
+
+	outputs, err = export_artifacts_utils.RecordOutputs(params.Build)
+	if err != nil {
+		return err
+	}
+	for key := range uncachedOutputs {
+		delete(outputs, key)
+	}
+
+	if err := Store(params.Config.Dir, fingerprint, params.ProjectDir, outputs); err != nil {
+		print.Warning("⚠️ Could not store the build in the cache: " + err.Error())
+		return nil
+	}
+	if err := includeInBitriseCache(params.Config.Dir); err != nil {
+		print.Warning("⚠️ Could not add the build cache to " + BitriseCacheIncludePathsEnvKey + ": " + err.Error())
+	}
+	print.Success("✅ Build stored in the cache: " + params.Config.Dir)
+	return nil
+}
+
+func fingerprintFor(params RunParams) (string, error) {
+	inputs, err := params.Runner.BuildInputs()
+	if err != nil {
+		return "", err
+	}
+	return Fingerprint(params.ProjectDir, inputs)
+}
+
+// exportOutputs exports again the outputs of the cached build, in a stable order.
+func exportOutputs(outputs map[string]string) error {
+	for _, key := range sortedKeys(outputs) {
+		if err := export_artifacts_utils.ExportEnv(key, outputs[key]); err != nil {
+			return fmt.Errorf("failed to export %s: %w", key, err)
+		}
+		print.Success(fmt.Sprintf("Artifact: %s exported into: %s", outputs[key], key))
+	}
+	return nil
+}
+
+// includeInBitriseCache adds the cache dir to the paths saved by the Bitrise cache steps.
+func includeInBitriseCache(dir string) error {
+	current := os.Getenv(BitriseCacheIncludePathsEnvKey)
+	for _, line := range strings.Split(current, "\n") {
+		if strings.TrimSpace(line) == dir {
+			return nil
+		}
+	}
+
+	paths := dir
+	if strings.TrimSpace(current) != "" {
+		paths = strings.TrimRight(current, "\n") + "\n" + dir
+	}
+	if err := os.Setenv(BitriseCacheIncludePathsEnvKey, paths); err != nil {
+		return err
+	}
+	return export_artifacts_utils.ExportEnv(BitriseCacheIncludePathsEnvKey, paths)
+}
+
+// uncachedOutputs describe the build that actually ran, they are not exported again on a cache hit.
+var uncachedOutputs = map[string]bool{
+	build.LogPathEnvKey:        true,
+	build.AndroidLogPathEnvKey: true,
+	build.IOSLogPathEnvKey:     true,
+}
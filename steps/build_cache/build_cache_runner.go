@@ -0,0 +1,46 @@
+package build_cache
+
+import (
+	"os"
+	"strings"
+
+	v "github.com/Masterminds/semver/v3"
+
+	build_constants "patrol_install/steps/build/constants"
+	getEnv "patrol_install/steps/build/steps/create_parameters"
+	flutter "patrol_install/steps/validate/get_flutter_version"
+)
+
+type BuildCacheRunner struct {
+	CLIVersion  *v.Version
+	DartVersion *v.Version
+}
+
+func (p *BuildCacheRunner) BuildInputs() (map[string]string, error) {
+	params, err := getEnv.BuildParametersFromEnv()
+	if err != nil {
+		return nil, err
+	}
+
+	inputs := map[string]string{
+		"build":         strings.Join(params.Command(), "\n"),
+		"shardCount":    os.Getenv(build_constants.ShardCount),
+		"shardStrategy": os.Getenv(build_constants.ShardStrategy),
+		"patrolCLI":     versionString(p.CLIVersion),
+		"dart":          versionString(p.DartVersion),
+	}
+
+	flutterInfo, err := flutter.GetFlutterInfo(nil)
+	if err != nil {
+		return nil, err
+	}
+	inputs["flutter"] = flutterInfo.Version.String() + " " + flutterInfo.FrameworkRevision + " " + flutterInfo.EngineRevision
+	return inputs, nil
+}
+
+func versionString(version *v.Version) string {
+	if version == nil {
+		return ""
+	}
+	return version.String()
+}
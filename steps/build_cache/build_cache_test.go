@@ -0,0 +1,253 @@
+package build_cache
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	build_constants "patrol_install/steps/build/constants"
+	export_artifacts_utils "patrol_install/steps/export_artifacts/utils"
+)
+
+type buildCacheStub struct {
+	inputs map[string]string
+}
+
+func (s *buildCacheStub) BuildInputs() (map[string]string, error) {
+	return s.inputs, nil
+}
+
+type exporterSpy struct {
+	exported map[string]string
+}
+
+func (s *exporterSpy) Export(key, value string) error {
+	s.exported[key] = value
+	return nil
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatalf("failed to create dir: %v", err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write %s: %v", path, err)
+	}
+}
+
+func exampleProject(t *testing.T) string {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "pubspec.lock"), "packages: {}\n")
+	writeFile(t, filepath.Join(root, "lib", "main.dart"), "void main() {}\n")
+	writeFile(t, filepath.Join(root, "patrol_test", "app_test.dart"), "void main() {}\n")
+	writeFile(t, filepath.Join(root, "android", "app", "build.gradle"), "android {}\n")
+	return root
+}
+
+func spyExporter(t *testing.T) *exporterSpy {
+	spy := &exporterSpy{exported: map[string]string{}}
+	export_artifacts_utils.SetEnvExporter(spy)
+	t.Cleanup(func() {
+		export_artifacts_utils.SetEnvExporter(nil)
+	})
+	return spy
+}
+
+func TestFingerprint(t *testing.T) {
+	root := exampleProject(t)
+	inputs := map[string]string{"build": "patrol build android --release"}
+
+	first, err := Fingerprint(root, inputs)
+	if err != nil {
+		t.Fatalf("Fingerprint() error: %v", err)
+	}
+
+	// Generated and machine specific files are ignored
+	writeFile(t, filepath.Join(root, "android", "local.properties"), "sdk.dir=/opt/android\n")
+	writeFile(t, filepath.Join(root, "android", "app", "build", "outputs.txt"), "generated\n")
+	if again, _ := Fingerprint(root, inputs); again != first {
+		t.Error("expected generated files not to change the fingerprint")
+	}
+
+	if changed, _ := Fingerprint(root, map[string]string{"build": "patrol build android --debug"}); changed == first {
+		t.Error("expected the build command to change the fingerprint")
+	}
+
+	writeFile(t, filepath.Join(root, "lib", "main.dart"), "void main() { run(); }\n")
+	if changed, _ := Fingerprint(root, inputs); changed == first {
+		t.Error("expected a source change to change the fingerprint")
+	}
+}
+
+func TestFingerprint_PubspecInputs(t *testing.T) {
+	// GIVEN a project with assets, a localization config and a path dependency outside the project
+	workspace := t.TempDir()
+	root := filepath.Join(workspace, "app")
+	writeFile(t, filepath.Join(root, "pubspec.yaml"), `name: app
+flutter:
+  assets:
+    - assets/images/
+  fonts:
+    - family: Inter
+      fonts:
+        - asset: fonts/Inter.ttf
+`)
+	writeFile(t, filepath.Join(root, "pubspec.lock"), `packages:
+  design_system:
+    dependency: "direct main"
+    description:
+      path: "../packages/design_system"
+      relative: true
+    source: path
+    version: "1.0.0"
+`)
+	writeFile(t, filepath.Join(root, "lib", "main.dart"), "void main() {}\n")
+	writeFile(t, filepath.Join(root, "l10n.yaml"), "arb-dir: lib/l10n\n")
+	writeFile(t, filepath.Join(root, "assets", "images", "logo.png"), "png")
+	writeFile(t, filepath.Join(root, "fonts", "Inter.ttf"), "ttf")
+	writeFile(t, filepath.Join(workspace, "packages", "design_system", "lib", "button.dart"), "class Button {}\n")
+	writeFile(t, filepath.Join(workspace, "packages", "design_system", ".dart_tool", "cache"), "generated")
+
+	first, err := Fingerprint(root, nil)
+	if err != nil {
+		t.Fatalf("Fingerprint() error: %v", err)
+	}
+
+	// WHEN one of the inputs declared outside lib changes
+	// THEN the fingerprint changes
+	changes := []struct {
+		name string
+		path string
+	}{
+		{name: "asset directory", path: filepath.Join(root, "assets", "images", "logo.png")},
+		{name: "font", path: filepath.Join(root, "fonts", "Inter.ttf")},
+		{name: "l10n.yaml", path: filepath.Join(root, "l10n.yaml")},
+		{name: "path dependency", path: filepath.Join(workspace, "packages", "design_system", "lib", "button.dart")},
+	}
+	previous := first
+	for _, change := range changes {
+		writeFile(t, change.path, "changed "+change.name)
+		changed, err := Fingerprint(root, nil)
+		if err != nil {
+			t.Fatalf("Fingerprint() error: %v", err)
+		}
+		if changed == previous {
+			t.Errorf("expected a change to the %s to change the fingerprint", change.name)
+		}
+		previous = changed
+	}
+
+	// Generated content of path dependencies is ignored
+	writeFile(t, filepath.Join(workspace, "packages", "design_system", ".dart_tool", "cache"), "regenerated")
+	if again, _ := Fingerprint(root, nil); again != previous {
+		t.Error("expected generated files of a path dependency not to change the fingerprint")
+	}
+}
+
+func TestStoreAndRestore(t *testing.T) {
+	root := exampleProject(t)
+	cacheDir := t.TempDir()
+	writeFile(t, filepath.Join(root, "patrol", "android", "app-release.apk"), "apk")
+	outputs := map[string]string{"ANDROID_APK_PATH": "patrol/android/app-release.apk"}
+
+	if err := Store(cacheDir, "old", root, map[string]string{}); err != nil {
+		t.Fatalf("Store() error: %v", err)
+	}
+	if err := Store(cacheDir, "abc", root, outputs); err != nil {
+		t.Fatalf("Store() error: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(cacheDir, "old")); !os.IsNotExist(err) {
+		t.Error("expected the previous entry to be pruned")
+	}
+
+	if err := os.RemoveAll(filepath.Join(root, "patrol")); err != nil {
+		t.Fatalf("failed to clean artifacts: %v", err)
+	}
+
+	restored, hit, err := Restore(cacheDir, "abc", root)
+	if err != nil || !hit {
+		t.Fatalf("Restore() = %v, %v, want a hit", hit, err)
+	}
+	if restored["ANDROID_APK_PATH"] != "patrol/android/app-release.apk" {
+		t.Errorf("unexpected outputs %v", restored)
+	}
+	if data, err := os.ReadFile(filepath.Join(root, "patrol", "android", "app-release.apk")); err != nil || string(data) != "apk" {
+		t.Errorf("expected the APK to be restored, got %q, %v", data, err)
+	}
+
+	if _, hit, err := Restore(cacheDir, "missing", root); hit || err != nil {
+		t.Errorf("Restore() of a missing entry = %v, %v, want a miss", hit, err)
+	}
+}
+
+func TestRun_MissThenHit(t *testing.T) {
+	root := exampleProject(t)
+	t.Setenv(BitriseCacheIncludePathsEnvKey, "")
+	spy := spyExporter(t)
+	params := RunParams{
+		Runner:     &buildCacheStub{inputs: map[string]string{"build": "patrol build android --release"}},
+		Config:     &Config{Enabled: true, Dir: filepath.Join(t.TempDir(), "cache")},
+		ProjectDir: root,
+	}
+
+	builds := 0
+	params.Build = func() error {
+		builds++
+		writeFile(t, filepath.Join(root, "patrol", "android", "app-release.apk"), "apk")
+		return export_artifacts_utils.CurrentEnvExporter().Export("ANDROID_APK_PATH", "patrol/android/app-release.apk")
+	}
+
+	// WHEN running twice with the same inputs
+	if err := Run(params); err != nil {
+		t.Fatalf("first Run() error: %v", err)
+	}
+	if spy.exported["ANDROID_APK_PATH"] == "" {
+		t.Fatal("expected the build outputs to be forwarded")
+	}
+	if spy.exported[BitriseCacheIncludePathsEnvKey] != params.Config.Dir {
+		t.Errorf("expected the cache dir to be added to the Bitrise cache, got %q", spy.exported[BitriseCacheIncludePathsEnvKey])
+	}
+
+	delete(spy.exported, "ANDROID_APK_PATH")
+	if err := Run(params); err != nil {
+		t.Fatalf("second Run() error: %v", err)
+	}
+
+	// THEN the second run exports the cached artifacts without building
+	if builds != 1 {
+		t.Errorf("expected a single build, got %d", builds)
+	}
+	if spy.exported["ANDROID_APK_PATH"] != "patrol/android/app-release.apk" {
+		t.Errorf("expected the cached output to be exported, got %v", spy.exported)
+	}
+}
+
+func TestRun_Disabled(t *testing.T) {
+	builds := 0
+	err := Run(RunParams{Config: &Config{}, Build: func() error {
+		builds++
+		return nil
+	}})
+	if err != nil || builds != 1 {
+		t.Fatalf("expected a plain build, got builds=%d err=%v", builds, err)
+	}
+}
+
+func TestConfigFromEnv(t *testing.T) {
+	t.Setenv(build_constants.BuildCache, "true")
+	t.Setenv(build_constants.BuildCacheDir, "/tmp/patrol-cache")
+
+	config, err := ConfigFromEnv()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !config.Enabled || config.Dir != "/tmp/patrol-cache" {
+		t.Errorf("unexpected config %+v", config)
+	}
+
+	t.Setenv(build_constants.BuildCache, "yes")
+	if _, err := ConfigFromEnv(); err == nil {
+		t.Error("expected error for an invalid BUILD_CACHE")
+	}
+}
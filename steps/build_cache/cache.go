@@ -0,0 +1,126 @@
+package build_cache
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	export_android_artifacts "patrol_install/steps/export_artifacts/export_android_artifacts"
+	export_ios_artifacts "patrol_install/steps/export_artifacts/export_ios_artifacts"
+	export_artifacts_utils "patrol_install/steps/export_artifacts/utils"
+	"patrol_install/steps/sharding"
+)
+
+const (
+	entryArtifactsDir = "artifacts"
+	entryOutputsFile  = "outputs.json"
+)
+
+// cachedArtifacts are the exported artifacts stored in a cache entry, relative to the project.
+var cachedArtifacts = []string{
+	export_android_artifacts.AndroidArtifactsPath,
+	export_ios_artifacts.IOSArtifactsPath,
+	sharding.ManifestPath,
+}
+
+// Store saves the exported artifacts of the project and the outputs exported for them under the
+// fingerprint. Entries of other fingerprints are removed, so the cache only holds the latest build.
+func Store(cacheDir, fingerprint, projectDir string, outputs map[string]string) error {
+	if err := os.MkdirAll(cacheDir, 0755); err != nil {
+		return fmt.Errorf("failed to create folder %s: %w", cacheDir, err)
+	}
+
+	staging, err := os.MkdirTemp(cacheDir, ".staging-")
+	if err != nil {
+		return fmt.Errorf("failed to create cache entry: %w", err)
+	}
+	defer os.RemoveAll(staging)
+
+	for _, artifact := range cachedArtifacts {
+		source := filepath.Join(projectDir, artifact)
+		if _, err := os.Stat(source); err != nil {
+			continue
+		}
+		if err := copyPath(source, filepath.Join(staging, entryArtifactsDir, artifact)); err != nil {
+			return fmt.Errorf("failed to cache %s: %w", artifact, err)
+		}
+	}
+
+	data, err := json.MarshalIndent(outputs, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(staging, entryOutputsFile), data, 0644); err != nil {
+		return fmt.Errorf("failed to write cache entry: %w", err)
+	}
+
+	if err := pruneEntries(cacheDir); err != nil {
+		return err
+	}
+	if err := os.Rename(staging, filepath.Join(cacheDir, fingerprint)); err != nil {
+		return fmt.Errorf("failed to store cache entry: %w", err)
+	}
+	return nil
+}
+
+// Restore copies the cached artifacts of the fingerprint back into the project and returns the
+// outputs to export again. It reports false without error when there is no entry for the fingerprint.
+func Restore(cacheDir, fingerprint, projectDir string) (map[string]string, bool, error) {
+	entry := filepath.Join(cacheDir, fingerprint)
+	data, err := os.ReadFile(filepath.Join(entry, entryOutputsFile))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to read cache entry: %w", err)
+	}
+
+	outputs := map[string]string{}
+	if err := json.Unmarshal(data, &outputs); err != nil {
+		return nil, false, fmt.Errorf("invalid cache entry %s: %w", entry, err)
+	}
+
+	for _, artifact := range cachedArtifacts {
+		source := filepath.Join(entry, entryArtifactsDir, artifact)
+		if _, err := os.Stat(source); err != nil {
+			continue
+		}
+		destination := filepath.Join(projectDir, artifact)
+		if err := os.RemoveAll(destination); err != nil {
+			return nil, false, fmt.Errorf("failed to replace %s: %w", destination, err)
+		}
+		if err := copyPath(source, destination); err != nil {
+			return nil, false, fmt.Errorf("failed to restore %s: %w", artifact, err)
+		}
+	}
+	return outputs, true, nil
+}
+
+// pruneEntries removes the stored entries, keeping in progress staging folders.
+func pruneEntries(cacheDir string) error {
+	entries, err := os.ReadDir(cacheDir)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", cacheDir, err)
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		if err := os.RemoveAll(filepath.Join(cacheDir, entry.Name())); err != nil {
+			return fmt.Errorf("failed to remove the previous cache entry: %w", err)
+		}
+	}
+	return nil
+}
+
+// copyPath copies a file or directory, creating the parent of the destination.
+func copyPath(source, destination string) error {
+	if err := os.MkdirAll(filepath.Dir(destination), 0755); err != nil {
+		return err
+	}
+	return export_artifacts_utils.CopyDir(source, destination)
+}
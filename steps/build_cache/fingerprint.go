@@ -0,0 +1,190 @@
+package build_cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+
+	verify_target "patrol_install/steps/build/steps/verify_target"
+	"patrol_install/utils/pubspec"
+)
+
+// fingerprintFiles are the project files that change the build output, including the
+// top-level config read by code generators such as gen-l10n and build_runner.
+var fingerprintFiles = []string{"pubspec.yaml", "pubspec.lock", "l10n.yaml", "build.yaml"}
+
+// fingerprintDirs are hashed recursively; the test directory, the assets and the path
+// dependencies are added from pubspec.yaml and pubspec.lock, see pubspecPaths.
+var fingerprintDirs = []string{"lib", "android", "ios"}
+
+// Generated or machine specific content that doesn't change the build output.
+var skippedDirs = map[string]bool{
+	"build":        true,
+	".gradle":      true,
+	".cxx":         true,
+	".idea":        true,
+	".dart_tool":   true,
+	"Pods":         true,
+	".symlinks":    true,
+	"ephemeral":    true,
+	"DerivedData":  true,
+	"xcuserdata":   true,
+	"node_modules": true,
+}
+
+var skippedFiles = map[string]bool{
+	"local.properties":              true,
+	"Generated.xcconfig":            true,
+	"flutter_export_environment.sh": true,
+	".DS_Store":                     true,
+}
+
+// Fingerprint hashes the project sources and configuration that affect `patrol build`,
+// together with the given inputs, e.g. the build command and the tool versions.
+func Fingerprint(projectDir string, inputs map[string]string) (string, error) {
+	hash := sha256.New()
+
+	for _, key := range sortedKeys(inputs) {
+		fmt.Fprintf(hash, "input %s=%s\n", key, inputs[key])
+	}
+
+	files, err := fingerprintedFiles(projectDir)
+	if err != nil {
+		return "", err
+	}
+	for _, file := range files {
+		sum, err := fileSum(filepath.Join(projectDir, file))
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(hash, "file %s %s\n", filepath.ToSlash(file), sum)
+	}
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// fingerprintedFiles lists the files included in the fingerprint, relative to projectDir and sorted.
+func fingerprintedFiles(projectDir string) ([]string, error) {
+	var files []string
+	for _, file := range fingerprintFiles {
+		if _, err := os.Stat(filepath.Join(projectDir, file)); err == nil {
+			files = append(files, file)
+		}
+	}
+
+	dirs := append(append([]string{}, fingerprintDirs...), verify_target.TestDirectory(projectDir))
+	dirs = append(dirs, pubspecPaths(projectDir)...)
+	for _, dir := range dirs {
+		root := filepath.Join(projectDir, dir)
+		err := filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+			if err != nil {
+				if errors.Is(err, fs.ErrNotExist) && path == root {
+					return filepath.SkipDir
+				}
+				return err
+			}
+			if entry.IsDir() {
+				if path != root && skippedDirs[entry.Name()] {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if skippedFiles[entry.Name()] || !entry.Type().IsRegular() {
+				return nil
+			}
+			relative, err := filepath.Rel(projectDir, path)
+			if err != nil {
+				return err
+			}
+			files = append(files, relative)
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to hash %s: %w", root, err)
+		}
+	}
+
+	sort.Strings(files)
+	return uniqueSorted(files), nil
+}
+
+// pubspecPaths returns the asset paths declared in pubspec.yaml and the directories of the path
+// dependencies resolved in pubspec.lock, relative to projectDir. Unreadable files add no path,
+// `patrol build` fails on them anyway.
+func pubspecPaths(projectDir string) []string {
+	var paths []string
+	if config, err := pubspec.ReadPubspec(filepath.Join(projectDir, pubspec.FileName)); err == nil {
+		for _, asset := range config.AssetPaths() {
+			paths = append(paths, filepath.Clean(filepath.FromSlash(asset)))
+		}
+	}
+
+	lockfile, err := pubspec.ReadLockfile(filepath.Join(projectDir, pubspec.LockFileName))
+	if err != nil {
+		return paths
+	}
+	for _, name := range sortedPackageNames(lockfile) {
+		locked := lockfile.Packages[name]
+		if locked.Source != pubspec.SourcePath || locked.Description.Path == "" {
+			continue
+		}
+		dir := filepath.FromSlash(locked.Description.Path)
+		if filepath.IsAbs(dir) {
+			relative, err := filepath.Rel(projectDir, dir)
+			if err != nil {
+				continue
+			}
+			dir = relative
+		}
+		paths = append(paths, filepath.Clean(dir))
+	}
+	return paths
+}
+
+func sortedPackageNames(lockfile *pubspec.Lockfile) []string {
+	names := make([]string, 0, len(lockfile.Packages))
+	for name := range lockfile.Packages {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func uniqueSorted(values []string) []string {
+	var unique []string
+	for i, value := range values {
+		if i == 0 || value != values[i-1] {
+			unique = append(unique, value)
+		}
+	}
+	return unique
+}
+
+func fileSum(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", fmt.Errorf("failed to hash %s: %w", path, err)
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+func sortedKeys(values map[string]string) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
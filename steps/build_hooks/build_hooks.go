@@ -0,0 +1,144 @@
+package build_hooks
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	build "patrol_install/steps/build"
+	build_constants "patrol_install/steps/build/constants"
+	"patrol_install/steps/build/executor"
+	bp "patrol_install/steps/build/models/build_parameters"
+	export_artifacts_utils "patrol_install/steps/export_artifacts/utils"
+	"patrol_install/utils/print"
+)
+
+const (
+	HookPreBuild  = "pre-build"
+	HookPostBuild = "post-build"
+
+	// OnFailureFail fails the step when the script fails, OnFailureWarn only reports it.
+	OnFailureFail = "fail"
+	OnFailureWarn = "warn"
+)
+
+type BuildHooks interface {
+	// BuildParameters returns the resolved build settings passed to the scripts.
+	BuildParameters() (*bp.BuildParameters, error)
+}
+
+// Hook is a script run before or after the build, inline or the path of a script file.
+type Hook struct {
+	Name      string
+	Script    string
+	OnFailure string
+}
+
+// Config is the hooks requested with PRE_BUILD_SCRIPT and POST_BUILD_SCRIPT, nil when not set.
+type Config struct {
+	Pre  *Hook
+	Post *Hook
+}
+
+type RunParams struct {
+	Runner     BuildHooks
+	Config     *Config
+	ProjectDir string
+	// Build builds and exports the artifacts, between the two hooks.
+	Build func() error
+}
+
+// ConfigFromEnv reads the scripts and their failure semantics, failing by default.
+func ConfigFromEnv() (*Config, error) {
+	pre, err := hookFromEnv(HookPreBuild, build_constants.PreBuildScript, build_constants.PreBuildScriptOnFailure)
+	if err != nil {
+		return nil, err
+	}
+	post, err := hookFromEnv(HookPostBuild, build_constants.PostBuildScript, build_constants.PostBuildScriptOnFailure)
+	if err != nil {
+		return nil, err
+	}
+	return &Config{Pre: pre, Post: post}, nil
+}
+
+func hookFromEnv(name, scriptKey, onFailureKey string) (*Hook, error) {
+	onFailure := OnFailureFail
+	switch value := strings.ToLower(strings.TrimSpace(os.Getenv(onFailureKey))); value {
+	case "", OnFailureFail:
+	case OnFailureWarn:
+		onFailure = OnFailureWarn
+	default:
+		return nil, fmt.Errorf("invalid value for %s: expected '%s' or '%s'", onFailureKey, OnFailureFail, OnFailureWarn)
+	}
+
+	script := os.Getenv(scriptKey)
+	if strings.TrimSpace(script) == "" {
+		return nil, nil
+	}
+	return &Hook{Name: name, Script: script, OnFailure: onFailure}, nil
+}
+
+// Run runs the pre-build script, the build, then the post-build script. The post-build script
+// also runs when the build failed, with PATROL_BUILD_STATUS and the outputs exported by the build.
+func Run(params RunParams) error {
+	if params.Config == nil || (params.Config.Pre == nil && params.Config.Post == nil) {
+		return params.Build()
+	}
+
+	buildParams, err := params.Runner.BuildParameters()
+	if err != nil {
+		print.Error("❌ " + err.Error())
+		return err
+	}
+
+	if params.Config.Pre != nil {
+		env := scriptEnv(buildParams, params.ProjectDir)
+		if err := runHook(params.Config.Pre, params.ProjectDir, env); err != nil {
+			return err
+		}
+	}
+
+	outputs, buildErr := export_artifacts_utils.RecordOutputs(params.Build)
+
+	if params.Config.Post != nil {
+		env := append(scriptEnv(buildParams, params.ProjectDir), postBuildEnv(outputs, buildErr)...)
+		if err := runHook(params.Config.Post, params.ProjectDir, env); err != nil {
+			return errors.Join(buildErr, err)
+		}
+	}
+	return buildErr
+}
+
+// runHook runs the script with the builder executor and applies its failure semantics.
+func runHook(hook *Hook, projectDir string, env []string) error {
+	print.StepInitiated(fmt.Sprintf("--- Running %s script ---", hook.Name))
+
+	command, err := scriptCommand(hook.Script, projectDir)
+	if err != nil {
+		return hookFailure(hook, err)
+	}
+
+	options, err := build.OptionsFromEnv()
+	if err != nil {
+		return hookFailure(hook, err)
+	}
+	options.Prefix = "[" + hook.Name + "]"
+	options.Dir = projectDir
+	options.Env = env
+
+	if _, err := executor.Execute(command, options); err != nil {
+		return hookFailure(hook, err)
+	}
+	print.StepCompleted(fmt.Sprintf("✅ %s script succeeded\n", hook.Name))
+	return nil
+}
+
+func hookFailure(hook *Hook, err error) error {
+	if hook.OnFailure == OnFailureWarn {
+		print.Warning(fmt.Sprintf("⚠️ %s script failed, continuing: %s", hook.Name, err))
+		return nil
+	}
+	print.Error(fmt.Sprintf("❌ %s script failed: %s", hook.Name, err))
+	return fmt.Errorf("%s script failed: %w", hook.Name, err)
+}
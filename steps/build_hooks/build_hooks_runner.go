@@ -0,0 +1,12 @@
+package build_hooks
+
+import (
+	bp "patrol_install/steps/build/models/build_parameters"
+	getEnv "patrol_install/steps/build/steps/create_parameters"
+)
+
+type BuildHooksRunner struct{}
+
+func (p *BuildHooksRunner) BuildParameters() (*bp.BuildParameters, error) {
+	return getEnv.BuildParametersFromEnv()
+}
@@ -0,0 +1,185 @@
+package build_hooks
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	build_constants "patrol_install/steps/build/constants"
+	bp "patrol_install/steps/build/models/build_parameters"
+	export_artifacts_utils "patrol_install/steps/export_artifacts/utils"
+)
+
+type hooksStub struct{}
+
+func (hooksStub) BuildParameters() (*bp.BuildParameters, error) {
+	return &bp.BuildParameters{Platform: "android", Target: "patrol_test", BuildType: "release"}, nil
+}
+
+type exporterSpy struct {
+	exported map[string]string
+}
+
+func (s *exporterSpy) Export(key, value string) error {
+	s.exported[key] = value
+	return nil
+}
+
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read %s: %v", path, err)
+	}
+	return string(data)
+}
+
+func TestConfigFromEnv(t *testing.T) {
+	t.Setenv(build_constants.PreBuildScript, "dart run build_runner build")
+	t.Setenv(build_constants.PreBuildScriptOnFailure, "")
+	t.Setenv(build_constants.PostBuildScript, "  ")
+	t.Setenv(build_constants.PostBuildScriptOnFailure, "warn")
+
+	config, err := ConfigFromEnv()
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if config.Pre == nil || config.Pre.OnFailure != OnFailureFail || config.Pre.Name != HookPreBuild {
+		t.Errorf("unexpected pre-build hook %+v", config.Pre)
+	}
+	if config.Post != nil {
+		t.Errorf("expected no post-build hook, got %+v", config.Post)
+	}
+
+	t.Setenv(build_constants.PreBuildScriptOnFailure, "ignore")
+	if _, err := ConfigFromEnv(); err == nil {
+		t.Error("expected an error for an unknown failure semantic")
+	}
+}
+
+func TestScriptCommand(t *testing.T) {
+	project := t.TempDir()
+	if err := os.WriteFile(filepath.Join(project, "prepare.sh"), []byte("echo prepare\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(project, "run me.sh"), []byte("#!/bin/bash\necho run\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		script string
+		want   string
+	}{
+		{name: "inline", script: "dart run build_runner build", want: "dart run build_runner build"},
+		{name: "multiline", script: "prepare.sh\necho done", want: "prepare.sh\necho done"},
+		{name: "path", script: "prepare.sh", want: "sh '" + filepath.Join(project, "prepare.sh") + "'"},
+		{name: "executable", script: " run me.sh\n", want: "'" + filepath.Join(project, "run me.sh") + "'"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := scriptCommand(tt.script, project)
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("scriptCommand(%q) = %q, want %q", tt.script, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRun_PassesBuildAndOutputsToScripts(t *testing.T) {
+	// GIVEN hooks writing their environment into the project
+	project := t.TempDir()
+	spy := &exporterSpy{exported: map[string]string{}}
+	export_artifacts_utils.SetEnvExporter(spy)
+	t.Cleanup(func() {
+		export_artifacts_utils.SetEnvExporter(nil)
+	})
+	var calls []string
+
+	err := Run(RunParams{
+		Runner: hooksStub{},
+		Config: &Config{
+			Pre:  &Hook{Name: HookPreBuild, Script: `echo "$PATROL_BUILD_PLATFORM $PATROL_BUILD_TARGET" > pre.txt`, OnFailure: OnFailureFail},
+			Post: &Hook{Name: HookPostBuild, Script: `echo "$PATROL_BUILD_STATUS $ANDROID_APK_PATH" > post.txt`, OnFailure: OnFailureFail},
+		},
+		ProjectDir: project,
+		Build: func() error {
+			calls = append(calls, "build")
+			return export_artifacts_utils.CurrentEnvExporter().Export("ANDROID_APK_PATH", "/out/app.apk")
+		},
+	})
+
+	// THEN both run in the project dir around the build, with its parameters and outputs
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(calls) != 1 {
+		t.Errorf("expected one build, got %v", calls)
+	}
+	if got := readFile(t, filepath.Join(project, "pre.txt")); got != "android patrol_test\n" {
+		t.Errorf("pre-build env = %q", got)
+	}
+	if got := readFile(t, filepath.Join(project, "post.txt")); got != "succeeded /out/app.apk\n" {
+		t.Errorf("post-build env = %q", got)
+	}
+	if spy.exported["ANDROID_APK_PATH"] != "/out/app.apk" {
+		t.Error("expected the output to still be exported")
+	}
+}
+
+func TestRun_PreBuildFailure(t *testing.T) {
+	tests := []struct {
+		name      string
+		onFailure string
+		wantBuild bool
+		wantErr   bool
+	}{
+		{name: "fail", onFailure: OnFailureFail, wantBuild: false, wantErr: true},
+		{name: "warn", onFailure: OnFailureWarn, wantBuild: true, wantErr: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			built := false
+			err := Run(RunParams{
+				Runner:     hooksStub{},
+				Config:     &Config{Pre: &Hook{Name: HookPreBuild, Script: "exit 2", OnFailure: tt.onFailure}},
+				ProjectDir: t.TempDir(),
+				Build: func() error {
+					built = true
+					return nil
+				},
+			})
+			if built != tt.wantBuild || (err != nil) != tt.wantErr {
+				t.Errorf("built = %v, err = %v", built, err)
+			}
+		})
+	}
+}
+
+func TestRun_PostBuildRunsAfterFailedBuild(t *testing.T) {
+	project := t.TempDir()
+	buildErr := errors.New("gradle failed")
+
+	err := Run(RunParams{
+		Runner:     hooksStub{},
+		Config:     &Config{Post: &Hook{Name: HookPostBuild, Script: `echo "$PATROL_BUILD_STATUS" > post.txt`, OnFailure: OnFailureFail}},
+		ProjectDir: project,
+		Build: func() error {
+			return buildErr
+		},
+	})
+
+	if !errors.Is(err, buildErr) {
+		t.Errorf("expected the build error, got %v", err)
+	}
+	if got := strings.TrimSpace(readFile(t, filepath.Join(project, "post.txt"))); got != BuildStatusFailed {
+		t.Errorf("PATROL_BUILD_STATUS = %q, want %q", got, BuildStatusFailed)
+	}
+}
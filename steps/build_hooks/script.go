@@ -0,0 +1,95 @@
+package build_hooks
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	bp "patrol_install/steps/build/models/build_parameters"
+	export_android_artifacts "patrol_install/steps/export_artifacts/export_android_artifacts"
+	export_ios_artifacts "patrol_install/steps/export_artifacts/export_ios_artifacts"
+)
+
+const (
+	BuildStatusSucceeded = "succeeded"
+	BuildStatusFailed    = "failed"
+)
+
+// scriptCommand returns the shell command running the script. A single line naming an existing
+// file, relative to the project, runs that file: directly when it is executable, with sh otherwise.
+// Anything else is run as an inline script.
+func scriptCommand(script, projectDir string) (string, error) {
+	path := strings.TrimSpace(script)
+	if strings.Contains(path, "\n") {
+		return script, nil
+	}
+
+	resolved := path
+	if !filepath.IsAbs(resolved) {
+		resolved = filepath.Join(projectDir, path)
+	}
+	info, err := os.Stat(resolved)
+	if err != nil || info.IsDir() {
+		return script, nil
+	}
+
+	absolute, err := filepath.Abs(resolved)
+	if err != nil {
+		return "", err
+	}
+	if info.Mode()&0111 != 0 {
+		return shellQuote(absolute), nil
+	}
+	return "sh " + shellQuote(absolute), nil
+}
+
+// scriptEnv describes the build to the scripts.
+func scriptEnv(params *bp.BuildParameters, projectDir string) []string {
+	verbose := "false"
+	if params.IsVerbose != "" {
+		verbose = "true"
+	}
+	return []string{
+		"PATROL_BUILD_PLATFORM=" + params.Platform,
+		"PATROL_BUILD_TARGET=" + params.Target,
+		"PATROL_BUILD_TYPE=" + params.BuildType,
+		"PATROL_BUILD_TAGS=" + params.Tags,
+		"PATROL_BUILD_EXCLUDED_TAGS=" + params.ExcludedTags,
+		"PATROL_BUILD_VERBOSE=" + verbose,
+		"PATROL_BUILD_COMMANDS=" + strings.Join(params.Command(), "\n"),
+		"PATROL_ANDROID_ARTIFACTS_DIR=" + artifactsDir(projectDir, export_android_artifacts.AndroidArtifactsPath),
+		"PATROL_IOS_ARTIFACTS_DIR=" + artifactsDir(projectDir, export_ios_artifacts.IOSArtifactsPath),
+	}
+}
+
+// postBuildEnv adds the result of the build and the outputs it exported, such as ANDROID_APK_PATH.
+func postBuildEnv(outputs map[string]string, buildErr error) []string {
+	status := BuildStatusSucceeded
+	if buildErr != nil {
+		status = BuildStatusFailed
+	}
+	env := []string{"PATROL_BUILD_STATUS=" + status}
+
+	keys := make([]string, 0, len(outputs))
+	for key := range outputs {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		env = append(env, key+"="+outputs[key])
+	}
+	return env
+}
+
+func artifactsDir(projectDir, path string) string {
+	absolute, err := filepath.Abs(filepath.Join(projectDir, path))
+	if err != nil {
+		return filepath.Join(projectDir, path)
+	}
+	return absolute
+}
+
+func shellQuote(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", `'\''`) + "'"
+}
@@ -0,0 +1,70 @@
+package doctor
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	doctor_report "patrol_install/steps/doctor/doctor_report"
+	"patrol_install/utils/print"
+)
+
+type Doctor interface {
+	// GetDoctorReport returns the parsed report, along with the exit error when `patrol doctor` fails.
+	// The report is nil only when the command produced no output.
+	GetDoctorReport() (*doctor_report.DoctorReport, error)
+}
+
+type DoctorRunParams struct {
+	Runner Doctor
+	// Platform is the PLATFORM being built, used to select the relevant checks.
+	Platform string
+	// RequireChecks fails the run when a check relevant to Platform is missing.
+	RequireChecks bool
+}
+
+// Run collects and prints the `patrol doctor` report. The report is returned even when Run fails,
+// so it can be included in later failure output.
+func Run(params DoctorRunParams) (*doctor_report.DoctorReport, error) {
+	print.StepInitiated("--- Checking build environment ---")
+
+	report, err := params.Runner.GetDoctorReport()
+	if report == nil {
+		if err == nil {
+			err = errors.New("patrol doctor printed no report")
+		}
+		if params.RequireChecks {
+			print.Error("❌ Failed to run patrol doctor: " + err.Error())
+			return nil, err
+		}
+		print.Warning("⚠️ Failed to run patrol doctor, skipping environment checks: " + err.Error())
+		return nil, nil
+	}
+
+	if err != nil {
+		// A missing requirement makes patrol doctor exit non-zero, the checks below tell whether it matters
+		print.Warning("⚠️ patrol doctor reported a failure: " + err.Error())
+	}
+
+	print.Vanilla(report.Table())
+
+	failed := report.FailedChecksFor(strings.ToLower(params.Platform))
+	if len(failed) == 0 {
+		print.StepCompleted("✅ Build environment is ready\n")
+		return report, nil
+	}
+
+	descriptions := make([]string, 0, len(failed))
+	for _, check := range failed {
+		descriptions = append(descriptions, check.String())
+	}
+
+	if !params.RequireChecks {
+		print.Warning(fmt.Sprintf("⚠️ Missing requirements for %s:\n  %s\n", params.Platform, strings.Join(descriptions, "\n  ")))
+		return report, nil
+	}
+
+	err = fmt.Errorf("missing requirements for %s:\n  %s", params.Platform, strings.Join(descriptions, "\n  "))
+	print.Error("❌ " + err.Error())
+	return report, err
+}
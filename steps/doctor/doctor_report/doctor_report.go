@@ -0,0 +1,202 @@
+package doctor_report
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strings"
+	"text/tabwriter"
+
+	build_constants "patrol_install/steps/build/constants"
+)
+
+const (
+	SectionGeneral = "general"
+	SectionAndroid = "android"
+	SectionIOS     = "ios"
+
+	KindProgram = "program"
+	KindEnvVar  = "env"
+)
+
+// DoctorCheck is a single tool or environment check reported by `patrol doctor`.
+type DoctorCheck struct {
+	Section string
+	Kind    string
+	Name    string
+	Passed  bool
+	Detail  string
+	Hint    string
+}
+
+// DoctorReport is the typed form of `patrol doctor --verbose` output.
+type DoctorReport struct {
+	CLIVersion     string
+	FlutterCommand string
+	FlutterVersion string
+	Checks         []DoctorCheck
+}
+
+var (
+	cliVersionLine     = regexp.MustCompile(`(?i)^Patrol CLI version:?\s*v?(\S+)`)
+	flutterCommandLine = regexp.MustCompile(`(?i)^Flutter command:?\s*(.*)$`)
+	flutterVersionLine = regexp.MustCompile(`^Flutter\s+(\d+\.\d+\.\d+\S*)`)
+	programFoundLine   = regexp.MustCompile(`(?i)^Program\s+(\S+)\s+found in\s*(.*)$`)
+	programMissingLine = regexp.MustCompile(`(?i)^Program\s+(\S+)\s+not found`)
+	envSetLine         = regexp.MustCompile(`(?i)^Env var\s+\$?(\S+)\s+set to\s*(.*)$`)
+	envMissingLine     = regexp.MustCompile(`(?i)^Env var\s+\$?(\S+)\s+(is )?not set`)
+)
+
+// Parse builds a DoctorReport from `patrol doctor --verbose` output. Unknown lines are ignored.
+func Parse(output string) *DoctorReport {
+	report := &DoctorReport{}
+	section := SectionGeneral
+
+	for _, rawLine := range strings.Split(output, "\n") {
+		indented := strings.HasPrefix(rawLine, "  ") || strings.HasPrefix(rawLine, "\t")
+		line := strings.TrimSpace(rawLine)
+		if line == "" {
+			continue
+		}
+
+		if next, ok := sectionOf(line); ok {
+			section = next
+			continue
+		}
+
+		if match := cliVersionLine.FindStringSubmatch(line); match != nil {
+			report.CLIVersion = match[1]
+			continue
+		}
+		if match := flutterCommandLine.FindStringSubmatch(line); match != nil {
+			report.FlutterCommand = strings.TrimSpace(match[1])
+			continue
+		}
+		if match := flutterVersionLine.FindStringSubmatch(line); match != nil && report.FlutterVersion == "" {
+			report.FlutterVersion = match[1]
+			continue
+		}
+
+		if check, ok := parseCheck(section, line); ok {
+			report.Checks = append(report.Checks, check)
+			continue
+		}
+
+		// Indented lines following a failed check are installation hints
+		if indented && len(report.Checks) > 0 {
+			last := &report.Checks[len(report.Checks)-1]
+			if !last.Passed && last.Hint == "" {
+				last.Hint = line
+			}
+		}
+	}
+
+	return report
+}
+
+func sectionOf(line string) (string, bool) {
+	header := strings.ToLower(strings.TrimSuffix(line, ":"))
+	switch {
+	case header == "android":
+		return SectionAndroid, true
+	case header == "ios / macos" || header == "ios" || header == "macos":
+		return SectionIOS, true
+	case header == "patrol doctor":
+		return SectionGeneral, true
+	}
+	return "", false
+}
+
+func parseCheck(section, line string) (DoctorCheck, bool) {
+	failedMarker := strings.HasPrefix(line, "✗") || strings.HasPrefix(line, "✘")
+	text := strings.TrimSpace(strings.TrimLeft(line, "•✓✔✗✘*- "))
+
+	if match := programMissingLine.FindStringSubmatch(text); match != nil {
+		return DoctorCheck{Section: section, Kind: KindProgram, Name: match[1]}, true
+	}
+	if match := programFoundLine.FindStringSubmatch(text); match != nil {
+		return DoctorCheck{Section: section, Kind: KindProgram, Name: match[1], Passed: !failedMarker, Detail: strings.TrimSpace(match[2])}, true
+	}
+	if match := envMissingLine.FindStringSubmatch(text); match != nil {
+		return DoctorCheck{Section: section, Kind: KindEnvVar, Name: match[1]}, true
+	}
+	if match := envSetLine.FindStringSubmatch(text); match != nil {
+		return DoctorCheck{Section: section, Kind: KindEnvVar, Name: match[1], Passed: !failedMarker, Detail: strings.TrimSpace(match[2])}, true
+	}
+	return DoctorCheck{}, false
+}
+
+// FailedChecksFor returns the failed checks relevant to the platform being built.
+func (r *DoctorReport) FailedChecksFor(platform string) []DoctorCheck {
+	var failed []DoctorCheck
+	for _, check := range r.Checks {
+		if !check.Passed && isRelevant(check.Section, platform) {
+			failed = append(failed, check)
+		}
+	}
+	return failed
+}
+
+func isRelevant(section, platform string) bool {
+	switch section {
+	case SectionAndroid:
+		return platform == build_constants.PlatformAndroid || platform == build_constants.PlatformBoth
+	case SectionIOS:
+		return platform == build_constants.PlatformIOS || platform == build_constants.PlatformBoth
+	default:
+		return true
+	}
+}
+
+// Table renders the report as an aligned text table.
+func (r *DoctorReport) Table() string {
+	var buf bytes.Buffer
+	w := tabwriter.NewWriter(&buf, 0, 0, 2, ' ', 0)
+
+	_, _ = fmt.Fprintf(w, "Patrol CLI\t%s\n", valueOrUnknown(r.CLIVersion))
+	_, _ = fmt.Fprintf(w, "Flutter\t%s\n", valueOrUnknown(r.FlutterVersion))
+	_, _ = fmt.Fprintln(w, "")
+	_, _ = fmt.Fprintln(w, "SECTION\tCHECK\tSTATUS\tDETAILS")
+	for _, check := range r.Checks {
+		status := "✅ ok"
+		detail := check.Detail
+		if !check.Passed {
+			status = "❌ missing"
+			detail = check.Hint
+		}
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", check.Section, check.label(), status, detail)
+	}
+	_ = w.Flush()
+
+	return strings.TrimRight(buf.String(), "\n")
+}
+
+func (c DoctorCheck) label() string {
+	if c.Kind == KindEnvVar {
+		return "$" + c.Name
+	}
+	return c.Name
+}
+
+// String describes the check in a single line, e.g. "android: program adb not found".
+func (c DoctorCheck) String() string {
+	state := "not found"
+	if c.Kind == KindEnvVar {
+		state = "not set"
+	}
+	if c.Passed {
+		state = "ok"
+	}
+	message := fmt.Sprintf("%s: %s %s %s", c.Section, c.Kind, c.label(), state)
+	if !c.Passed && c.Hint != "" {
+		message += " (" + c.Hint + ")"
+	}
+	return message
+}
+
+func valueOrUnknown(value string) string {
+	if value == "" {
+		return "unknown"
+	}
+	return value
+}
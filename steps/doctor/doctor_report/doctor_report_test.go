@@ -0,0 +1,123 @@
+package doctor_report
+
+import (
+	"strings"
+	"testing"
+
+	build_constants "patrol_install/steps/build/constants"
+)
+
+const doctorAllPassing = `Patrol doctor:
+Patrol CLI version: 3.11.0
+Flutter command: flutter 
+  Flutter 3.32.0 • channel stable
+Android: 
+• Program adb found in /Users/ci/Library/Android/sdk/platform-tools/adb
+• Env var $ANDROID_HOME set to /Users/ci/Library/Android/sdk
+iOS / macOS: 
+• Program xcodebuild found in /usr/bin/xcodebuild
+• Program ideviceinstaller found in /opt/homebrew/bin/ideviceinstaller
+`
+
+const doctorLinuxAgent = `Patrol doctor:
+Patrol CLI version: 4.0.1
+Flutter command: flutter 
+  Flutter 3.35.7 • channel stable
+Android: 
+✗ Program adb not found
+  Install Android SDK Platform-Tools
+✗ Env var $ANDROID_HOME is not set
+• Env var $JAVA_HOME set to /usr/lib/jvm/java-17
+iOS / macOS: 
+✗ Program xcodebuild not found
+✗ Program ios-deploy not found
+  brew install ios-deploy
+`
+
+func TestParse_AllPassing(t *testing.T) {
+	report := Parse(doctorAllPassing)
+
+	if report.CLIVersion != "3.11.0" {
+		t.Errorf("CLIVersion = %q, want 3.11.0", report.CLIVersion)
+	}
+	if report.FlutterCommand != "flutter" {
+		t.Errorf("FlutterCommand = %q, want flutter", report.FlutterCommand)
+	}
+	if report.FlutterVersion != "3.32.0" {
+		t.Errorf("FlutterVersion = %q, want 3.32.0", report.FlutterVersion)
+	}
+	if len(report.Checks) != 4 {
+		t.Fatalf("expected 4 checks, got %d: %+v", len(report.Checks), report.Checks)
+	}
+
+	adb := report.Checks[0]
+	if adb.Section != SectionAndroid || adb.Kind != KindProgram || adb.Name != "adb" || !adb.Passed {
+		t.Errorf("unexpected adb check %+v", adb)
+	}
+	if adb.Detail != "/Users/ci/Library/Android/sdk/platform-tools/adb" {
+		t.Errorf("unexpected adb detail %q", adb.Detail)
+	}
+	androidHome := report.Checks[1]
+	if androidHome.Kind != KindEnvVar || androidHome.Name != "ANDROID_HOME" || !androidHome.Passed {
+		t.Errorf("unexpected ANDROID_HOME check %+v", androidHome)
+	}
+	if report.Checks[2].Section != SectionIOS {
+		t.Errorf("expected xcodebuild in the iOS section, got %q", report.Checks[2].Section)
+	}
+	if failed := report.FailedChecksFor(build_constants.PlatformBoth); len(failed) != 0 {
+		t.Errorf("expected no failed checks, got %+v", failed)
+	}
+}
+
+func TestParse_MissingTools(t *testing.T) {
+	report := Parse(doctorLinuxAgent)
+
+	if len(report.Checks) != 5 {
+		t.Fatalf("expected 5 checks, got %d: %+v", len(report.Checks), report.Checks)
+	}
+	if report.Checks[0].Passed || report.Checks[0].Hint != "Install Android SDK Platform-Tools" {
+		t.Errorf("expected failed adb check with hint, got %+v", report.Checks[0])
+	}
+	if !report.Checks[2].Passed || report.Checks[2].Name != "JAVA_HOME" {
+		t.Errorf("expected passing JAVA_HOME check, got %+v", report.Checks[2])
+	}
+	if report.Checks[4].Name != "ios-deploy" || report.Checks[4].Hint != "brew install ios-deploy" {
+		t.Errorf("unexpected ios-deploy check %+v", report.Checks[4])
+	}
+}
+
+func TestFailedChecksFor(t *testing.T) {
+	report := Parse(doctorLinuxAgent)
+
+	tests := []struct {
+		platform string
+		want     int
+	}{
+		{platform: build_constants.PlatformAndroid, want: 2},
+		{platform: build_constants.PlatformIOS, want: 2},
+		{platform: build_constants.PlatformBoth, want: 4},
+	}
+
+	for _, tt := range tests {
+		if got := report.FailedChecksFor(tt.platform); len(got) != tt.want {
+			t.Errorf("FailedChecksFor(%s) returned %d checks, want %d", tt.platform, len(got), tt.want)
+		}
+	}
+}
+
+func TestTable(t *testing.T) {
+	table := Parse(doctorLinuxAgent).Table()
+
+	for _, want := range []string{"Patrol CLI", "4.0.1", "$ANDROID_HOME", "❌ missing", "✅ ok", "brew install ios-deploy"} {
+		if !strings.Contains(table, want) {
+			t.Errorf("expected table to contain %q, got:\n%s", want, table)
+		}
+	}
+}
+
+func TestDoctorCheckString(t *testing.T) {
+	check := DoctorCheck{Section: SectionAndroid, Kind: KindEnvVar, Name: "ANDROID_HOME"}
+	if got := check.String(); got != "android: env $ANDROID_HOME not set" {
+		t.Errorf("String() = %q", got)
+	}
+}
@@ -0,0 +1,37 @@
+package doctor
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"patrol_install/commands"
+	build_constants "patrol_install/steps/build/constants"
+	doctor_report "patrol_install/steps/doctor/doctor_report"
+	"patrol_install/utils/exec"
+)
+
+type DoctorRunner struct{}
+
+// GetDoctorReport parses the `patrol doctor` output whatever its exit code, as it exits non-zero
+// exactly when a requirement is missing. The exit error is returned alongside the report.
+func (p *DoctorRunner) GetDoctorReport() (*doctor_report.DoctorReport, error) {
+	output, err := exec.CombinedCommand(commands.PatrolDoctor)
+	if strings.TrimSpace(output) == "" {
+		return nil, err
+	}
+	return doctor_report.Parse(output), err
+}
+
+// RequireChecksFromEnv reads REQUIRE_DOCTOR_CHECKS, defaulting to false when empty.
+func RequireChecksFromEnv() (bool, error) {
+	value := strings.ToLower(strings.TrimSpace(os.Getenv(build_constants.RequireDoctorChecks)))
+	switch value {
+	case "", "false":
+		return false, nil
+	case "true":
+		return true, nil
+	default:
+		return false, fmt.Errorf("invalid value for %s: expected 'true' or 'false'", build_constants.RequireDoctorChecks)
+	}
+}
@@ -0,0 +1,104 @@
+package doctor
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	build_constants "patrol_install/steps/build/constants"
+	doctor_report "patrol_install/steps/doctor/doctor_report"
+)
+
+type doctorStub struct {
+	report *doctor_report.DoctorReport
+	err    error
+}
+
+func (d *doctorStub) GetDoctorReport() (*doctor_report.DoctorReport, error) {
+	return d.report, d.err
+}
+
+func missingAndroidSDK() *doctor_report.DoctorReport {
+	return &doctor_report.DoctorReport{
+		Checks: []doctor_report.DoctorCheck{
+			{Section: doctor_report.SectionAndroid, Kind: doctor_report.KindEnvVar, Name: "ANDROID_HOME"},
+			{Section: doctor_report.SectionIOS, Kind: doctor_report.KindProgram, Name: "xcodebuild", Passed: true},
+		},
+	}
+}
+
+func TestRun_RequiredChecksFailForBuiltPlatform(t *testing.T) {
+	// GIVEN a missing Android SDK while building Android with required checks
+	params := DoctorRunParams{
+		Runner:        &doctorStub{report: missingAndroidSDK()},
+		Platform:      build_constants.PlatformAndroid,
+		RequireChecks: true,
+	}
+
+	// WHEN running the doctor stage
+	report, err := Run(params)
+
+	// THEN it fails early and still returns the report
+	if err == nil {
+		t.Fatal("expected error, got nil")
+	}
+	if report == nil {
+		t.Fatal("expected the report to be returned with the error")
+	}
+}
+
+func TestRun_RequiredChecksIgnoreOtherPlatform(t *testing.T) {
+	// GIVEN a missing Android SDK while building iOS
+	params := DoctorRunParams{
+		Runner:        &doctorStub{report: missingAndroidSDK()},
+		Platform:      build_constants.PlatformIOS,
+		RequireChecks: true,
+	}
+
+	// WHEN running the doctor stage
+	_, err := Run(params)
+
+	// THEN the Android check does not fail the run
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+}
+
+func TestRun_MissingChecksOnlyWarnByDefault(t *testing.T) {
+	params := DoctorRunParams{
+		Runner:   &doctorStub{report: missingAndroidSDK()},
+		Platform: build_constants.PlatformBoth,
+	}
+
+	if _, err := Run(params); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+}
+
+func TestRun_ChecksReportOfFailedDoctor(t *testing.T) {
+	// GIVEN patrol doctor exiting non-zero because the Android SDK is missing
+	stub := &doctorStub{report: missingAndroidSDK(), err: errors.New("exit status 1")}
+
+	// WHEN running the doctor stage for each platform with required checks
+	iosReport, iosErr := Run(DoctorRunParams{Runner: stub, Platform: build_constants.PlatformIOS, RequireChecks: true})
+	_, androidErr := Run(DoctorRunParams{Runner: stub, Platform: build_constants.PlatformAndroid, RequireChecks: true})
+
+	// THEN the report is still checked, only the platform missing a requirement fails
+	if iosErr != nil || iosReport == nil {
+		t.Errorf("expected the iOS build to pass with the report, got %v (report: %v)", iosErr, iosReport)
+	}
+	if androidErr == nil || !strings.Contains(androidErr.Error(), "ANDROID_HOME") {
+		t.Errorf("expected the missing Android SDK to be reported, got %v", androidErr)
+	}
+}
+
+func TestRun_DoctorFailure(t *testing.T) {
+	stub := &doctorStub{err: errors.New("patrol: command not found")}
+
+	if _, err := Run(DoctorRunParams{Runner: stub, Platform: build_constants.PlatformBoth}); err != nil {
+		t.Fatalf("expected doctor failure to be skipped when checks are optional, got %v", err)
+	}
+	if _, err := Run(DoctorRunParams{Runner: stub, Platform: build_constants.PlatformBoth, RequireChecks: true}); err == nil {
+		t.Fatal("expected doctor failure to fail when checks are required")
+	}
+}
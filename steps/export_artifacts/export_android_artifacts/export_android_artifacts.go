@@ -13,9 +13,14 @@ import (
 
 // CopyAndroidArtifactsFromEnv derives paths from env and exports Android artifacts.
 func CopyAndroidArtifactsFromEnv() error {
+	return CopyAndroidArtifactsFromEnvTo(AndroidArtifactsPath)
+}
+
+// CopyAndroidArtifactsFromEnvTo derives the APK paths from env and exports Android artifacts into artifactsPath.
+func CopyAndroidArtifactsFromEnvTo(artifactsPath string) error {
 	isRelease := os.Getenv(build_constants.BuildType) == "release"
 	testPath, appPath := AndroidApkPaths(isRelease)
-	return CopyAndroidArtifacts(AndroidArtifactsPath, testPath, appPath)
+	return CopyAndroidArtifacts(artifactsPath, testPath, appPath)
 }
 
 // CopyAndroidArtifacts finds the first test and app APKs and copies them to the artifacts directory.
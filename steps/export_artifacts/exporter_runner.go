@@ -1,46 +1,152 @@
 package export_artifacts
 
 import (
+	"errors"
+	"fmt"
 	"os"
+	"path/filepath"
+	"slices"
 
+	build_report "patrol_install/steps/build/build_report"
 	build_constants "patrol_install/steps/build/constants"
 	export_android_artifacts "patrol_install/steps/export_artifacts/export_android_artifacts"
 	export_ios_artifacts "patrol_install/steps/export_artifacts/export_ios_artifacts"
+	export_artifacts_utils "patrol_install/steps/export_artifacts/utils"
 	print "patrol_install/utils/print"
 )
 
-var exportAndroid = func() error {
-	return export_android_artifacts.CopyAndroidArtifactsFromEnv()
+// exportAndroid and exportIOS copy the outputs of the last build into artifactsPath, variables so tests can stub them.
+var exportAndroid = func(artifactsPath string) error {
+	return export_android_artifacts.CopyAndroidArtifactsFromEnvTo(artifactsPath)
 }
 
-var exportIOS = func() error {
-	return export_ios_artifacts.CopyIOSArtifacts(export_ios_artifacts.IOSArtifactsPath)
+var exportIOS = func(artifactsPath string) error {
+	return export_ios_artifacts.CopyIOSArtifacts(artifactsPath)
 }
 
-type ExporterRunner struct{}
+type ExporterRunner struct {
+	// ContinueOnPlatformFailure exports each platform independently, skipping the FailedPlatforms.
+	ContinueOnPlatformFailure bool
+	// FailedPlatforms are the platforms whose build failed in this build, their outputs are missing or stale.
+	FailedPlatforms []string
+}
 
 func (p *ExporterRunner) FindAndExportAndroid() error {
-	return exportAndroid()
+	return exportAndroid(export_android_artifacts.AndroidArtifactsPath)
 }
 
 func (p *ExporterRunner) FindAndExportIOS() error {
-	return exportIOS()
+	return exportIOS(export_ios_artifacts.IOSArtifactsPath)
 }
 
 // FindAndExport runs platform-specific exports based on PLATFORM env.
 func (p *ExporterRunner) FindAndExport() error {
+	return exportForPlatform(p.FindAndExportAndroid, p.FindAndExportIOS, p.ContinueOnPlatformFailure, p.FailedPlatforms)
+}
+
+// ShardExporterRunner exports the artifacts of one shard into its shard_<index> folders. Its outputs
+// are exported with the ShardKeySuffix, the unsuffixed keys are left to unsharded builds.
+type ShardExporterRunner struct {
+	Index                     int
+	ContinueOnPlatformFailure bool
+	// FailedPlatforms are the platforms whose build failed for this shard, other shards don't matter.
+	FailedPlatforms []string
+}
+
+// ShardArtifactsPath returns the folder the artifacts of a shard are exported to inside platformPath.
+func ShardArtifactsPath(platformPath string, index int) string {
+	return filepath.Join(platformPath, fmt.Sprintf("shard_%d", index))
+}
+
+// ShardKeySuffix returns the suffix of the output keys of a shard, e.g. ANDROID_APK_PATH_SHARD_0.
+func ShardKeySuffix(index int) string {
+	return fmt.Sprintf("_SHARD_%d", index)
+}
+
+func (p *ShardExporterRunner) FindAndExport() error {
+	exportShardAndroid := func() error {
+		return exportAndroid(ShardArtifactsPath(export_android_artifacts.AndroidArtifactsPath, p.Index))
+	}
+	exportShardIOS := func() error {
+		return exportIOS(ShardArtifactsPath(export_ios_artifacts.IOSArtifactsPath, p.Index))
+	}
+	return export_artifacts_utils.ExportWithKeySuffix(ShardKeySuffix(p.Index), func() error {
+		return exportForPlatform(exportShardAndroid, exportShardIOS, p.ContinueOnPlatformFailure, p.FailedPlatforms)
+	})
+}
+
+func exportForPlatform(android, ios func() error, continueOnFailure bool, failedPlatforms []string) error {
+	android = exportPlatform(build_constants.PlatformAndroid, android, continueOnFailure, failedPlatforms)
+	ios = exportPlatform(build_constants.PlatformIOS, ios, continueOnFailure, failedPlatforms)
+
 	switch os.Getenv(build_constants.Platform) {
 	case build_constants.PlatformAndroid:
-		return p.FindAndExportAndroid()
+		return android()
 	case build_constants.PlatformIOS:
-		return p.FindAndExportIOS()
+		return ios()
 	case build_constants.PlatformBoth:
-		if err := p.FindAndExportAndroid(); err != nil {
+		if continueOnFailure {
+			return errors.Join(android(), ios())
+		}
+		if err := android(); err != nil {
 			return err
 		}
-		return p.FindAndExportIOS()
+		return ios()
 	default:
 		print.Action("No valid platform selected for export")
 		return nil
 	}
 }
+
+// exportPlatform records a failing export in the build report. When continueOnFailure is set,
+// a platform among failedPlatforms is skipped and the error names the platform.
+func exportPlatform(platform string, export func() error, continueOnFailure bool, failedPlatforms []string) func() error {
+	return func() error {
+		if continueOnFailure && slices.Contains(failedPlatforms, platform) {
+			print.Warning(fmt.Sprintf("⚠️ Skipping the %s export, its build failed", platform))
+			return nil
+		}
+
+		err := export()
+		if err == nil {
+			return nil
+		}
+		build_report.Current().SetPlatformStatus(platform, build_report.PlatformExportFailed)
+		if continueOnFailure {
+			print.Error(fmt.Sprintf("❌ %s export failed: %s", platform, err))
+			return &PlatformExportError{Platform: platform, Err: err}
+		}
+		return err
+	}
+}
+
+// PlatformExportError is the failed export of one platform, returned when exports continue on failure.
+type PlatformExportError struct {
+	Platform string
+	Err      error
+}
+
+func (e *PlatformExportError) Error() string {
+	return fmt.Sprintf("%s export: %s", e.Platform, e.Err)
+}
+
+func (e *PlatformExportError) Unwrap() error {
+	return e.Err
+}
+
+// FailedExports returns the platforms of the PlatformExportErrors in err, as returned by FindAndExport.
+func FailedExports(err error) []string {
+	errs := []error{err}
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		errs = joined.Unwrap()
+	}
+
+	var platforms []string
+	for _, err := range errs {
+		var exportError *PlatformExportError
+		if errors.As(err, &exportError) {
+			platforms = append(platforms, exportError.Platform)
+		}
+	}
+	return platforms
+}
@@ -2,14 +2,28 @@ package export_artifacts
 
 import (
 	"errors"
+	"path/filepath"
 	"testing"
 
+	build_report "patrol_install/steps/build/build_report"
 	build_constants "patrol_install/steps/build/constants"
+	export_android_artifacts "patrol_install/steps/export_artifacts/export_android_artifacts"
+	export_artifacts_utils "patrol_install/steps/export_artifacts/utils"
 )
 
+type exporterSpy struct {
+	exported map[string]string
+}
+
+func (s *exporterSpy) Export(key, value string) error {
+	s.exported[key] = value
+	return nil
+}
+
 type exportCallState struct {
 	androidCalled bool
 	iosCalled     bool
+	androidPath   string
 }
 
 func stubExports(t *testing.T, androidErr, iosErr error) *exportCallState {
@@ -17,11 +31,12 @@ func stubExports(t *testing.T, androidErr, iosErr error) *exportCallState {
 	originalAndroid := exportAndroid
 	originalIOS := exportIOS
 
-	exportAndroid = func() error {
+	exportAndroid = func(artifactsPath string) error {
 		state.androidCalled = true
+		state.androidPath = artifactsPath
 		return androidErr
 	}
-	exportIOS = func() error {
+	exportIOS = func(artifactsPath string) error {
 		state.iosCalled = true
 		return iosErr
 	}
@@ -122,3 +137,110 @@ func TestFindAndExport_IOSError(t *testing.T) {
 		t.Fatalf("expected ios export to run")
 	}
 }
+
+func TestFindAndExport_ContinueOnPlatformFailure(t *testing.T) {
+	// GIVEN both selected and the Android export fails
+	t.Setenv(build_constants.Platform, build_constants.PlatformBoth)
+	build_report.Reset()
+	t.Cleanup(build_report.Reset)
+	state := stubExports(t, errors.New("android failed"), nil)
+	runner := &ExporterRunner{ContinueOnPlatformFailure: true}
+
+	// WHEN running exports
+	err := runner.FindAndExport()
+
+	// THEN iOS is still exported and the Android failure is reported
+	if err == nil {
+		t.Fatalf("expected error, got nil")
+	}
+	if !state.iosCalled {
+		t.Fatalf("expected ios export to run after android failure")
+	}
+	if got := build_report.Current().PlatformStatus(build_constants.PlatformAndroid); got != build_report.PlatformExportFailed {
+		t.Errorf("android status = %q, want %q", got, build_report.PlatformExportFailed)
+	}
+	if got := FailedExports(err); len(got) != 1 || got[0] != build_constants.PlatformAndroid {
+		t.Errorf("FailedExports() = %v, want only android", got)
+	}
+}
+
+func TestFindAndExport_SkipsFailedBuild(t *testing.T) {
+	// GIVEN both selected and the Android build failed
+	t.Setenv(build_constants.Platform, build_constants.PlatformBoth)
+	state := stubExports(t, nil, nil)
+	runner := &ExporterRunner{ContinueOnPlatformFailure: true, FailedPlatforms: []string{build_constants.PlatformAndroid}}
+
+	// WHEN running exports
+	err := runner.FindAndExport()
+
+	// THEN only iOS is exported
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if state.androidCalled || !state.iosCalled {
+		t.Fatalf("expected only ios export, got android=%v ios=%v", state.androidCalled, state.iosCalled)
+	}
+}
+
+func TestShardFindAndExport_IgnoresFailuresOfOtherShards(t *testing.T) {
+	// GIVEN the Android build of shard 0 failed and shard 1 built both platforms
+	t.Setenv(build_constants.Platform, build_constants.PlatformBoth)
+	build_report.Reset()
+	t.Cleanup(build_report.Reset)
+	build_report.Current().SetPlatformStatus(build_constants.PlatformAndroid, build_report.PlatformBuildFailed)
+	state := stubExports(t, nil, nil)
+
+	shard0 := &ShardExporterRunner{Index: 0, ContinueOnPlatformFailure: true, FailedPlatforms: []string{build_constants.PlatformAndroid}}
+	if err := shard0.FindAndExport(); err != nil {
+		t.Fatalf("expected no error for shard 0, got %v", err)
+	}
+	if state.androidCalled {
+		t.Fatal("expected the failed Android build of shard 0 not to be exported")
+	}
+
+	// WHEN exporting shard 1
+	shard1 := &ShardExporterRunner{Index: 1, ContinueOnPlatformFailure: true}
+	err := shard1.FindAndExport()
+
+	// THEN both platforms of shard 1 are exported
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if !state.androidCalled || !state.iosCalled {
+		t.Fatalf("expected both exports for shard 1, got android=%v ios=%v", state.androidCalled, state.iosCalled)
+	}
+	if filepath.Base(state.androidPath) != "shard_1" {
+		t.Errorf("expected the shard 1 folder, got %q", state.androidPath)
+	}
+}
+
+func TestShardFindAndExport_ExportsShardKeys(t *testing.T) {
+	// GIVEN an Android shard
+	t.Setenv(build_constants.Platform, build_constants.PlatformAndroid)
+	stubExports(t, nil, nil)
+	exportAndroid = func(artifactsPath string) error {
+		return export_artifacts_utils.ExportEnv(export_android_artifacts.ApkPathEnvKey, filepath.Join(artifactsPath, "app.apk"))
+	}
+	spy := &exporterSpy{exported: map[string]string{}}
+	export_artifacts_utils.SetEnvExporter(spy)
+	t.Cleanup(func() {
+		export_artifacts_utils.SetEnvExporter(nil)
+	})
+
+	// WHEN exporting shard 2
+	if err := (&ShardExporterRunner{Index: 2}).FindAndExport(); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	// THEN only the shard key is exported and the exporter is restored
+	want := filepath.Join(export_android_artifacts.AndroidArtifactsPath, "shard_2", "app.apk")
+	if got := spy.exported["ANDROID_APK_PATH_SHARD_2"]; got != want {
+		t.Errorf("ANDROID_APK_PATH_SHARD_2 = %q, want %q (exported: %v)", got, want, spy.exported)
+	}
+	if _, ok := spy.exported[export_android_artifacts.ApkPathEnvKey]; ok {
+		t.Errorf("expected the global key not to be exported by a shard, got %v", spy.exported)
+	}
+	if export_artifacts_utils.CurrentEnvExporter() != spy {
+		t.Error("expected the exporter to be restored after the shard export")
+	}
+}
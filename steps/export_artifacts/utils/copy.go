@@ -111,3 +111,8 @@ func copyDir(srcDir, dstDir string) error {
 		return copyFile(path, dstPath, info.Mode())
 	})
 }
+
+// CopyDir copies srcDir recursively into dstDir, keeping file modes and symlinks.
+func CopyDir(srcDir, dstDir string) error {
+	return copyDir(srcDir, dstDir)
+}
@@ -15,7 +15,7 @@ func (envmanExporter) Export(key, value string) error {
 
 var envExporter EnvExporter = envmanExporter{}
 
-// SetEnvExporter swaps the exporter used by CopyFilesToFolder. Pass nil to reset to the default.
+// SetEnvExporter swaps the exporter used by CopyFilesToFolder and ExportEnv. Pass nil to reset to the default.
 func SetEnvExporter(exporter EnvExporter) {
 	if exporter == nil {
 		envExporter = envmanExporter{}
@@ -24,6 +24,16 @@ func SetEnvExporter(exporter EnvExporter) {
 	envExporter = exporter
 }
 
+// CurrentEnvExporter returns the exporter used by CopyFilesToFolder and ExportEnv, so it can be wrapped.
+func CurrentEnvExporter() EnvExporter {
+	return envExporter
+}
+
 func exportEnv(key, value string) error {
 	return envExporter.Export(key, value)
 }
+
+// ExportEnv exports the value with the configured exporter, envman by default.
+func ExportEnv(key, value string) error {
+	return exportEnv(key, value)
+}
@@ -0,0 +1,20 @@
+package export_artifacts_utils
+
+// ExportWithKeySuffix runs fn with every key it exports renamed to key+suffix, so the outputs of
+// repeated exports such as shards don't overwrite each other.
+func ExportWithKeySuffix(suffix string, fn func() error) error {
+	previous := CurrentEnvExporter()
+	SetEnvExporter(suffixExporter{suffix: suffix, forward: previous.Export})
+	defer SetEnvExporter(previous)
+
+	return fn()
+}
+
+type suffixExporter struct {
+	suffix  string
+	forward func(key, value string) error
+}
+
+func (e suffixExporter) Export(key, value string) error {
+	return e.forward(key+e.suffix, value)
+}
@@ -0,0 +1,43 @@
+package export_artifacts_utils
+
+import "sync"
+
+// RecordOutputs runs fn and returns the outputs it exported through the env exporter.
+// The outputs are still forwarded to the exporter in place before the call.
+func RecordOutputs(fn func() error) (map[string]string, error) {
+	recorder := &outputRecorder{outputs: map[string]string{}}
+	previous := CurrentEnvExporter()
+
+	SetEnvExporter(recordingExporter{recorder: recorder, forward: previous.Export})
+	defer SetEnvExporter(previous)
+
+	err := fn()
+	return recorder.snapshot(), err
+}
+
+type outputRecorder struct {
+	mu      sync.Mutex
+	outputs map[string]string
+}
+
+func (r *outputRecorder) snapshot() map[string]string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	outputs := make(map[string]string, len(r.outputs))
+	for key, value := range r.outputs {
+		outputs[key] = value
+	}
+	return outputs
+}
+
+type recordingExporter struct {
+	recorder *outputRecorder
+	forward  func(key, value string) error
+}
+
+func (e recordingExporter) Export(key, value string) error {
+	e.recorder.mu.Lock()
+	e.recorder.outputs[key] = value
+	e.recorder.mu.Unlock()
+	return e.forward(key, value)
+}
@@ -0,0 +1,132 @@
+package cli_constraint
+
+import (
+	"fmt"
+	"strings"
+
+	v "github.com/Masterminds/semver/v3"
+)
+
+// Operators understood by both Masterminds semver and `dart pub global activate`.
+var dartOperators = []string{">=", "<=", ">", "<", "^"}
+
+// CLIConstraint is a validated Patrol CLI version or version constraint, e.g. "3.9.0", "^3.9.0" or ">=3.7.0 <4.0.0".
+type CLIConstraint struct {
+	Raw         string
+	terms       []string
+	constraints *v.Constraints
+}
+
+// Parse validates the raw version request. It returns nil without error when the request is empty (latest).
+// Only the subset of syntax shared by Masterminds semver and pub is accepted, so the constraint
+// can be checked locally and passed through to `dart pub global activate` unchanged.
+func Parse(raw string) (*CLIConstraint, error) {
+	trimmed := strings.TrimSpace(raw)
+	if trimmed == "" {
+		return nil, nil
+	}
+
+	terms := strings.Fields(strings.ReplaceAll(trimmed, ",", " "))
+	for _, term := range terms {
+		if err := validateTerm(term); err != nil {
+			return nil, fmt.Errorf("invalid Patrol CLI version %q: %w", raw, err)
+		}
+	}
+
+	constraints, err := v.NewConstraint(strings.Join(terms, ", "))
+	if err != nil {
+		return nil, fmt.Errorf("invalid Patrol CLI version %q: %w", raw, err)
+	}
+
+	return &CLIConstraint{Raw: trimmed, terms: terms, constraints: constraints}, nil
+}
+
+// Check reports whether the given version satisfies the constraint.
+func (c *CLIConstraint) Check(version *v.Version) bool {
+	return c.constraints.Check(version)
+}
+
+// PubArgument returns the constraint in the form expected by `dart pub global activate`.
+func (c *CLIConstraint) PubArgument() string {
+	return strings.Join(c.terms, " ")
+}
+
+// Bound is one end of the versions allowed by a constraint, a nil Version means unbounded.
+type Bound struct {
+	Version   *v.Version
+	Inclusive bool
+}
+
+// Interval returns the versions allowed by the constraint as a single interval, its terms being ANDed.
+// A caret follows pub: ^1.2.3 allows up to 2.0.0, ^0.2.3 up to 0.3.0 and ^0.0.3 up to 0.0.4, excluded.
+func (c *CLIConstraint) Interval() (lower, upper Bound) {
+	for _, term := range c.terms {
+		operator := operatorOf(term)
+		version := v.MustParse(strings.TrimPrefix(term, operator))
+		switch operator {
+		case ">=":
+			lower = tighterLower(lower, Bound{Version: version, Inclusive: true})
+		case ">":
+			lower = tighterLower(lower, Bound{Version: version})
+		case "<=":
+			upper = tighterUpper(upper, Bound{Version: version, Inclusive: true})
+		case "<":
+			upper = tighterUpper(upper, Bound{Version: version})
+		case "^":
+			lower = tighterLower(lower, Bound{Version: version, Inclusive: true})
+			upper = tighterUpper(upper, Bound{Version: caretLimit(version)})
+		default:
+			lower = tighterLower(lower, Bound{Version: version, Inclusive: true})
+			upper = tighterUpper(upper, Bound{Version: version, Inclusive: true})
+		}
+	}
+	return lower, upper
+}
+
+func tighterLower(current, candidate Bound) Bound {
+	if current.Version == nil || candidate.Version.GreaterThan(current.Version) ||
+		candidate.Version.Equal(current.Version) && !candidate.Inclusive {
+		return candidate
+	}
+	return current
+}
+
+func tighterUpper(current, candidate Bound) Bound {
+	if current.Version == nil || candidate.Version.LessThan(current.Version) ||
+		candidate.Version.Equal(current.Version) && !candidate.Inclusive {
+		return candidate
+	}
+	return current
+}
+
+func caretLimit(version *v.Version) *v.Version {
+	switch {
+	case version.Major() > 0:
+		return v.New(version.Major()+1, 0, 0, "", "")
+	case version.Minor() > 0:
+		return v.New(0, version.Minor()+1, 0, "", "")
+	default:
+		return v.New(0, 0, version.Patch()+1, "", "")
+	}
+}
+
+func validateTerm(term string) error {
+	operator := operatorOf(term)
+	version := strings.TrimPrefix(term, operator)
+	if version == "" {
+		return fmt.Errorf("missing version after %q", operator)
+	}
+	if _, err := v.StrictNewVersion(version); err != nil {
+		return fmt.Errorf("%q is not a full semantic version (expected e.g. 3.9.0)", version)
+	}
+	return nil
+}
+
+func operatorOf(term string) string {
+	for _, operator := range dartOperators {
+		if strings.HasPrefix(term, operator) {
+			return operator
+		}
+	}
+	return ""
+}
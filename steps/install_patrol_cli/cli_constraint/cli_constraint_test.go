@@ -0,0 +1,119 @@
+package cli_constraint
+
+import (
+	"testing"
+
+	v "github.com/Masterminds/semver/v3"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		raw     string
+		wantPub string
+		wantErr bool
+	}{
+		{name: "exact_version", raw: "3.9.0", wantPub: "3.9.0"},
+		{name: "caret_constraint", raw: "^3.9.0", wantPub: "^3.9.0"},
+		{name: "range_constraint", raw: ">=3.7.0 <4.0.0", wantPub: ">=3.7.0 <4.0.0"},
+		{name: "comma_separated_range", raw: ">=3.7.0, <4.0.0", wantPub: ">=3.7.0 <4.0.0"},
+		{name: "surrounding_whitespace", raw: "  ^4.0.0 ", wantPub: "^4.0.0"},
+		{name: "partial_version", raw: "3.9", wantErr: true},
+		{name: "tilde_not_supported_by_pub", raw: "~3.9.0", wantErr: true},
+		{name: "or_not_supported_by_pub", raw: "3.9.0 || 4.0.0", wantErr: true},
+		{name: "wildcard", raw: "3.x", wantErr: true},
+		{name: "operator_without_version", raw: ">=", wantErr: true},
+		{name: "garbage", raw: "latest", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(tt.raw)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Parse(%q) error = %v, wantErr %v", tt.raw, err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if got.PubArgument() != tt.wantPub {
+				t.Errorf("PubArgument() = %q, want %q", got.PubArgument(), tt.wantPub)
+			}
+		})
+	}
+}
+
+func TestParse_EmptyMeansLatest(t *testing.T) {
+	got, err := Parse("  ")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if got != nil {
+		t.Fatalf("expected nil constraint, got %+v", got)
+	}
+}
+
+func TestCheck(t *testing.T) {
+	tests := []struct {
+		raw     string
+		version string
+		want    bool
+	}{
+		{raw: "3.9.0", version: "3.9.0", want: true},
+		{raw: "3.9.0", version: "4.0.1", want: false},
+		{raw: "^3.9.0", version: "3.10.0", want: true},
+		{raw: "^3.9.0", version: "4.0.0", want: false},
+		{raw: ">=3.7.0 <4.0.0", version: "3.11.0", want: true},
+		{raw: ">=3.7.0 <4.0.0", version: "4.0.0", want: false},
+	}
+
+	for _, tt := range tests {
+		constraint, err := Parse(tt.raw)
+		if err != nil {
+			t.Fatalf("Parse(%q) error: %v", tt.raw, err)
+		}
+		if got := constraint.Check(v.MustParse(tt.version)); got != tt.want {
+			t.Errorf("Parse(%q).Check(%s) = %v, want %v", tt.raw, tt.version, got, tt.want)
+		}
+	}
+}
+
+func TestInterval(t *testing.T) {
+	bound := func(version string, inclusive bool) Bound {
+		if version == "" {
+			return Bound{}
+		}
+		return Bound{Version: v.MustParse(version), Inclusive: inclusive}
+	}
+
+	tests := []struct {
+		raw   string
+		lower Bound
+		upper Bound
+	}{
+		{raw: "3.9.0", lower: bound("3.9.0", true), upper: bound("3.9.0", true)},
+		{raw: "^3.9.0", lower: bound("3.9.0", true), upper: bound("4.0.0", false)},
+		{raw: "^0.2.3", lower: bound("0.2.3", true), upper: bound("0.3.0", false)},
+		{raw: ">=3.9.5 <3.9.8", lower: bound("3.9.5", true), upper: bound("3.9.8", false)},
+		{raw: ">3.7.0", lower: bound("3.7.0", false), upper: bound("", false)},
+		{raw: ">=3.7.0 >3.7.0 <=4.0.0", lower: bound("3.7.0", false), upper: bound("4.0.0", true)},
+		{raw: "<=4.0.0 ^3.9.0", lower: bound("3.9.0", true), upper: bound("4.0.0", false)},
+	}
+
+	sameBound := func(a, b Bound) bool {
+		if a.Version == nil || b.Version == nil {
+			return a.Version == nil && b.Version == nil
+		}
+		return a.Version.Equal(b.Version) && a.Inclusive == b.Inclusive
+	}
+
+	for _, tt := range tests {
+		constraint, err := Parse(tt.raw)
+		if err != nil {
+			t.Fatalf("Parse(%q) error: %v", tt.raw, err)
+		}
+		lower, upper := constraint.Interval()
+		if !sameBound(lower, tt.lower) || !sameBound(upper, tt.upper) {
+			t.Errorf("Parse(%q).Interval() = (%+v, %+v), want (%+v, %+v)", tt.raw, lower, upper, tt.lower, tt.upper)
+		}
+	}
+}
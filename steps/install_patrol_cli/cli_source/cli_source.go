@@ -0,0 +1,127 @@
+package cli_source
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"patrol_install/commands"
+	constants "patrol_install/steps/build/constants"
+)
+
+const (
+	SourceHosted = "hosted"
+	SourceGit    = "git"
+	SourcePath   = "path"
+)
+
+// CLISource describes where `dart pub global activate` takes patrol_cli from.
+type CLISource struct {
+	Kind      string
+	GitURL    string
+	GitRef    string
+	GitPath   string
+	Path      string
+	HostedURL string
+}
+
+// FromEnv reads and validates the Patrol CLI source inputs.
+func FromEnv() (*CLISource, error) {
+	source := &CLISource{
+		Kind:      strings.ToLower(strings.TrimSpace(os.Getenv(constants.PatrolCLISource))),
+		GitURL:    strings.TrimSpace(os.Getenv(constants.PatrolCLIGitURL)),
+		GitRef:    strings.TrimSpace(os.Getenv(constants.PatrolCLIGitRef)),
+		GitPath:   strings.TrimSpace(os.Getenv(constants.PatrolCLIGitPath)),
+		Path:      strings.TrimSpace(os.Getenv(constants.PatrolCLIPath)),
+		HostedURL: strings.TrimSpace(os.Getenv(constants.PubHostedURL)),
+	}
+	if source.Kind == "" {
+		source.Kind = SourceHosted
+	}
+
+	if err := source.Validate(); err != nil {
+		return nil, err
+	}
+	return source, nil
+}
+
+// Validate checks that the inputs required by the selected source are present.
+func (s *CLISource) Validate() error {
+	switch s.Kind {
+	case SourceHosted:
+		return nil
+	case SourceGit:
+		if s.GitURL == "" {
+			return fmt.Errorf("%s is required when %s is 'git'", constants.PatrolCLIGitURL, constants.PatrolCLISource)
+		}
+		return nil
+	case SourcePath:
+		if s.Path == "" {
+			return fmt.Errorf("%s is required when %s is 'path'", constants.PatrolCLIPath, constants.PatrolCLISource)
+		}
+		info, err := os.Stat(s.Path)
+		if err != nil {
+			return fmt.Errorf("invalid %s %q: %w", constants.PatrolCLIPath, s.Path, err)
+		}
+		if !info.IsDir() {
+			return fmt.Errorf("invalid %s %q: expected a directory", constants.PatrolCLIPath, s.Path)
+		}
+		return nil
+	default:
+		return fmt.Errorf("invalid %s: expected 'hosted', 'git' or 'path', got %q", constants.PatrolCLISource, s.Kind)
+	}
+}
+
+// IsHosted reports whether patrol_cli is activated from a pub server.
+func (s *CLISource) IsHosted() bool {
+	return s.Kind == SourceHosted
+}
+
+// Description returns a short human readable description of the source.
+func (s *CLISource) Description() string {
+	switch s.Kind {
+	case SourceGit:
+		if s.GitRef != "" {
+			return fmt.Sprintf("git %s@%s", s.GitURL, s.GitRef)
+		}
+		return "git " + s.GitURL
+	case SourcePath:
+		return "path " + s.Path
+	default:
+		if s.HostedURL != "" {
+			return "hosted " + s.HostedURL
+		}
+		return "pub.dev"
+	}
+}
+
+// ActivateCommand returns the `dart pub global activate` command for this source.
+// The version constraint only applies to hosted sources and is ignored otherwise.
+func (s *CLISource) ActivateCommand(constraint string) commands.Command {
+	activate := commands.PubGlobalActivate
+	args := append([]string{}, activate.Args...)
+
+	switch s.Kind {
+	case SourceGit:
+		args = append(args, "--source", SourceGit, s.GitURL)
+		if s.GitRef != "" {
+			args = append(args, "--git-ref", s.GitRef)
+		}
+		if s.GitPath != "" {
+			args = append(args, "--git-path", s.GitPath)
+		}
+		return activate.CopyWith(nil, args)
+	case SourcePath:
+		args = append(args, "--source", SourcePath, s.Path)
+		return activate.CopyWith(nil, args)
+	}
+
+	if s.HostedURL != "" {
+		args = append(args, "--hosted-url", s.HostedURL)
+	}
+	args = append(args, commands.PatrolCLIPackage)
+	if constraint != "" {
+		args = append(args, constraint)
+	}
+	return commands.PatrolInstall.CopyWith(nil, args)
+}
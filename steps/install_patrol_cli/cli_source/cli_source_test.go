@@ -0,0 +1,131 @@
+package cli_source
+
+import (
+	"reflect"
+	"testing"
+
+	constants "patrol_install/steps/build/constants"
+)
+
+func clearSourceEnv(t *testing.T) {
+	for _, key := range []string{
+		constants.PatrolCLISource, constants.PatrolCLIGitURL, constants.PatrolCLIGitRef,
+		constants.PatrolCLIGitPath, constants.PatrolCLIPath, constants.PubHostedURL,
+	} {
+		t.Setenv(key, "")
+	}
+}
+
+func TestFromEnv_DefaultsToHosted(t *testing.T) {
+	// GIVEN no source inputs
+	clearSourceEnv(t)
+
+	// WHEN reading the source
+	source, err := FromEnv()
+
+	// THEN the hosted source is used
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if !source.IsHosted() {
+		t.Fatalf("expected hosted source, got %q", source.Kind)
+	}
+}
+
+func TestFromEnv_Validation(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     map[string]string
+		wantErr bool
+	}{
+		{name: "git_without_url", env: map[string]string{constants.PatrolCLISource: "git"}, wantErr: true},
+		{name: "git_with_url", env: map[string]string{constants.PatrolCLISource: "git", constants.PatrolCLIGitURL: "https://example.com/patrol.git"}},
+		{name: "path_without_path", env: map[string]string{constants.PatrolCLISource: "path"}, wantErr: true},
+		{name: "path_missing_directory", env: map[string]string{constants.PatrolCLISource: "path", constants.PatrolCLIPath: "/does/not/exist"}, wantErr: true},
+		{name: "unknown_source", env: map[string]string{constants.PatrolCLISource: "svn"}, wantErr: true},
+		{name: "uppercase_source", env: map[string]string{constants.PatrolCLISource: "HOSTED"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clearSourceEnv(t)
+			for key, value := range tt.env {
+				t.Setenv(key, value)
+			}
+			_, err := FromEnv()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("FromEnv() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestFromEnv_PathSource(t *testing.T) {
+	// GIVEN a local checkout of patrol_cli
+	clearSourceEnv(t)
+	dir := t.TempDir()
+	t.Setenv(constants.PatrolCLISource, "path")
+	t.Setenv(constants.PatrolCLIPath, dir)
+
+	// WHEN reading the source
+	source, err := FromEnv()
+
+	// THEN the path is accepted
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if source.Path != dir {
+		t.Fatalf("expected path %q, got %q", dir, source.Path)
+	}
+}
+
+func TestActivateCommand(t *testing.T) {
+	tests := []struct {
+		name       string
+		source     CLISource
+		constraint string
+		wantArgs   []string
+	}{
+		{
+			name:     "hosted_latest",
+			source:   CLISource{Kind: SourceHosted},
+			wantArgs: []string{"pub", "global", "activate", "patrol_cli"},
+		},
+		{
+			name:       "hosted_with_constraint",
+			source:     CLISource{Kind: SourceHosted},
+			constraint: "^3.9.0",
+			wantArgs:   []string{"pub", "global", "activate", "patrol_cli", "^3.9.0"},
+		},
+		{
+			name:       "private_pub_server",
+			source:     CLISource{Kind: SourceHosted, HostedURL: "https://pub.example.com"},
+			constraint: "3.9.0",
+			wantArgs:   []string{"pub", "global", "activate", "--hosted-url", "https://pub.example.com", "patrol_cli", "3.9.0"},
+		},
+		{
+			name:       "git_ignores_constraint",
+			source:     CLISource{Kind: SourceGit, GitURL: "https://example.com/patrol.git", GitRef: "fix", GitPath: "packages/patrol_cli"},
+			constraint: "3.9.0",
+			wantArgs: []string{"pub", "global", "activate", "--source", "git", "https://example.com/patrol.git",
+				"--git-ref", "fix", "--git-path", "packages/patrol_cli"},
+		},
+		{
+			name:     "path",
+			source:   CLISource{Kind: SourcePath, Path: "../patrol_cli"},
+			wantArgs: []string{"pub", "global", "activate", "--source", "path", "../patrol_cli"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := tt.source.ActivateCommand(tt.constraint)
+			if cmd.Name != "dart" {
+				t.Errorf("expected dart command, got %q", cmd.Name)
+			}
+			if !reflect.DeepEqual(cmd.Args, tt.wantArgs) {
+				t.Errorf("ActivateCommand() args = %v, want %v", cmd.Args, tt.wantArgs)
+			}
+		})
+	}
+}
@@ -2,6 +2,7 @@ package get_cli_version
 
 import (
 	"fmt"
+	"regexp"
 	"strings"
 
 	v "github.com/Masterminds/semver/v3"
@@ -9,30 +10,114 @@ import (
 	"patrol_install/commands"
 	regex "patrol_install/constants"
 	"patrol_install/utils/exec"
+	"patrol_install/utils/print"
 )
 
-var patrolDoctor = commands.PatrolDoctor
+// Detection methods, from fastest to slowest.
+const (
+	MethodPubGlobalList = "dart pub global list"
+	MethodPatrolVersion = "patrol --version"
+	MethodPatrolDoctor  = "patrol doctor"
+)
+
+type CommandExecutor func(cmd commands.Command) (string, error)
+
+// Detection is the detected Patrol CLI version and the method that found it.
+type Detection struct {
+	Version *v.Version
+	Method  string
+}
+
+type detector struct {
+	method string
+	cmd    commands.Command
+	parse  func(output string) (*v.Version, error)
+}
+
+var detectors = []detector{
+	{method: MethodPubGlobalList, cmd: commands.DartPubGlobalList, parse: ParsePubGlobalList},
+	{method: MethodPatrolVersion, cmd: commands.PatrolVersion, parse: ParsePatrolVersion},
+	{method: MethodPatrolDoctor, cmd: commands.PatrolDoctor, parse: ParsePatrolDoctor},
+}
 
-func GetPatrolCLIVersion() (*v.Version, error) {
-	output, err := exec.Command(patrolDoctor)
+var pubGlobalListLine = regexp.MustCompile(`^patrol_cli\s+v?(\S+)`)
+var patrolVersionLine = regexp.MustCompile(`(?im)^\s*patrol_cli\s+v?(\d+\.\d+\.\d+\S*)`)
+
+// GetPatrolCLIVersion detects the installed Patrol CLI with the default executor.
+func GetPatrolCLIVersion() (*Detection, error) {
+	detection, err := DetectPatrolCLIVersion(nil)
 	if err != nil {
 		return nil, err
 	}
 
-	// Use regex to extract the version
+	print.Action(fmt.Sprintf("Patrol CLI %s detected via `%s`", detection.Version.String(), detection.Method))
+	return detection, nil
+}
+
+// DetectPatrolCLIVersion tries each detection method in order and returns the first version found.
+// The executor parameter allows for dependency injection in tests. Pass nil to use the default executor.
+func DetectPatrolCLIVersion(executor CommandExecutor) (*Detection, error) {
+	run := executor
+	if run == nil {
+		run = exec.Command
+	}
+
+	var failures []string
+	for _, d := range detectors {
+		output, err := run(d.cmd)
+		if err == nil {
+			var version *v.Version
+			version, err = d.parse(output)
+			if err == nil {
+				return &Detection{Version: version, Method: d.method}, nil
+			}
+		}
+		failures = append(failures, fmt.Sprintf("%s: %s", d.method, err))
+	}
+
+	return nil, fmt.Errorf("could not detect Patrol CLI version (%s)", strings.Join(failures, "; "))
+}
+
+// ParsePubGlobalList extracts the patrol_cli version from `dart pub global list` output, e.g.
+// "patrol_cli 3.9.0" or "patrol_cli 3.9.0 from Git repository "https://...".
+func ParsePubGlobalList(output string) (*v.Version, error) {
+	for _, line := range strings.Split(output, "\n") {
+		match := pubGlobalListLine.FindStringSubmatch(strings.TrimSpace(line))
+		if len(match) > 1 {
+			return parseVersion(match[1])
+		}
+	}
+	return nil, fmt.Errorf("patrol_cli is not globally activated")
+}
+
+// ParsePatrolVersion extracts the version from `patrol --version` output, e.g. "patrol_cli v3.9.0".
+func ParsePatrolVersion(output string) (*v.Version, error) {
+	match := patrolVersionLine.FindStringSubmatch(output)
+	if len(match) > 1 {
+		return parseVersion(match[1])
+	}
+	return ParsePatrolDoctor(output)
+}
+
+// ParsePatrolDoctor extracts the version from `patrol doctor` output, e.g. "Patrol CLI version: 3.9.0".
+func ParsePatrolDoctor(output string) (*v.Version, error) {
 	re := regex.Version("Patrol CLI Version")
 	match := re.FindStringSubmatch(output)
 	if len(match) > 1 {
-		parsedVersion, err := v.NewVersion(cleanVersion(match[1]))
-		if err != nil {
-			return nil, fmt.Errorf("invalid semantic version: %w", err)
-		}
-		return parsedVersion, nil
+		return parseVersion(match[1])
 	}
 
 	return nil, fmt.Errorf("could not find version in output")
 }
 
+func parseVersion(raw string) (*v.Version, error) {
+	parsedVersion, err := v.NewVersion(cleanVersion(raw))
+	if err != nil {
+		return nil, fmt.Errorf("invalid semantic version: %w", err)
+	}
+	return parsedVersion, nil
+}
+
 func cleanVersion(version string) string {
 	version = strings.TrimSpace(version)
 	version = strings.TrimPrefix(version, "v")
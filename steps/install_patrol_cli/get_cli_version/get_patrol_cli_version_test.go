@@ -0,0 +1,188 @@
+package get_cli_version
+
+import (
+	"errors"
+	"testing"
+
+	"patrol_install/commands"
+	commands_utils "patrol_install/commands/utils"
+)
+
+const pubGlobalListHosted = `devtools 2.37.3
+flutterfire_cli 1.0.0
+patrol_cli 3.9.0
+`
+
+const pubGlobalListGit = `patrol_cli 4.0.1 from Git repository "https://github.com/acme/patrol.git"
+`
+
+const pubGlobalListPath = `melos 6.1.0
+patrol_cli 2.6.5 at path "/Users/ci/patrol/packages/patrol_cli"
+`
+
+const patrolVersion2x = `patrol_cli v2.6.5
+`
+
+const patrolVersion4x = `patrol_cli v4.0.1
+
+Update available! 4.0.1 → 4.1.0
+Run 'dart pub global activate patrol_cli' to update.
+`
+
+const patrolDoctor2x = `Patrol doctor:
+Patrol CLI version: 2.2.1
+Flutter command: flutter
+  Flutter 3.7.12 • channel stable
+Android:
+• Program adb found in /usr/local/android/platform-tools/adb
+• Env var $ANDROID_HOME set to /usr/local/android
+`
+
+const patrolDoctor3x = `Patrol doctor:
+Patrol CLI version: 3.11.0
+Flutter command: flutter 
+  Flutter 3.32.0 • channel stable
+Android: 
+• Program adb found in /Users/ci/Library/Android/sdk/platform-tools/adb
+• Env var $ANDROID_HOME set to /Users/ci/Library/Android/sdk
+iOS / macOS: 
+• Program xcodebuild found in /usr/bin/xcodebuild
+• Program ideviceinstaller found in /opt/homebrew/bin/ideviceinstaller
+`
+
+func TestParsePubGlobalList(t *testing.T) {
+	tests := []struct {
+		name    string
+		output  string
+		want    string
+		wantErr bool
+	}{
+		{name: "hosted", output: pubGlobalListHosted, want: "3.9.0"},
+		{name: "git", output: pubGlobalListGit, want: "4.0.1"},
+		{name: "path", output: pubGlobalListPath, want: "2.6.5"},
+		{name: "not_activated", output: "devtools 2.37.3\n", wantErr: true},
+		{name: "similar_package_name", output: "patrol_cli_extras 1.0.0\n", wantErr: true},
+		{name: "empty", output: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParsePubGlobalList(tt.output)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && got.String() != tt.want {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParsePatrolVersion(t *testing.T) {
+	tests := []struct {
+		name    string
+		output  string
+		want    string
+		wantErr bool
+	}{
+		{name: "cli_2x", output: patrolVersion2x, want: "2.6.5"},
+		{name: "cli_4x_with_update_notice", output: patrolVersion4x, want: "4.0.1"},
+		{name: "doctor_style_line", output: "Patrol CLI version: 3.4.1\n", want: "3.4.1"},
+		{name: "unrelated_output", output: "Could not find a command named \"--version\".", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParsePatrolVersion(tt.output)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && got.String() != tt.want {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParsePatrolDoctor(t *testing.T) {
+	tests := []struct {
+		name   string
+		output string
+		want   string
+	}{
+		{name: "cli_2x", output: patrolDoctor2x, want: "2.2.1"},
+		{name: "cli_3x", output: patrolDoctor3x, want: "3.11.0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParsePatrolDoctor(tt.output)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got.String() != tt.want {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDetectPatrolCLIVersion_PrefersPubGlobalList(t *testing.T) {
+	// GIVEN every command succeeds
+	var executed []commands.Command
+	executor := func(cmd commands.Command) (string, error) {
+		executed = append(executed, cmd)
+		return pubGlobalListHosted, nil
+	}
+
+	// WHEN detecting the version
+	detection, err := DetectPatrolCLIVersion(executor)
+
+	// THEN only pub global list is used
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if detection.Method != MethodPubGlobalList || len(executed) != 1 {
+		t.Fatalf("expected detection via pub global list only, got %s after %d commands", detection.Method, len(executed))
+	}
+}
+
+func TestDetectPatrolCLIVersion_FallsBackInOrder(t *testing.T) {
+	// GIVEN pub global list and patrol --version don't report a version
+	var executed []commands.Command
+	executor := func(cmd commands.Command) (string, error) {
+		executed = append(executed, cmd)
+		switch {
+		case commands_utils.IsSameCommand(cmd, commands.DartPubGlobalList):
+			return "devtools 2.37.3\n", nil
+		case commands_utils.IsSameCommand(cmd, commands.PatrolVersion):
+			return "", errors.New("exit status 64")
+		default:
+			return patrolDoctor3x, nil
+		}
+	}
+
+	// WHEN detecting the version
+	detection, err := DetectPatrolCLIVersion(executor)
+
+	// THEN patrol doctor is used last
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if detection.Method != MethodPatrolDoctor || detection.Version.String() != "3.11.0" {
+		t.Fatalf("expected 3.11.0 via patrol doctor, got %s via %s", detection.Version, detection.Method)
+	}
+	if len(executed) != 3 {
+		t.Fatalf("expected 3 commands, got %d", len(executed))
+	}
+}
+
+func TestDetectPatrolCLIVersion_AllMethodsFail(t *testing.T) {
+	executor := func(cmd commands.Command) (string, error) {
+		return "", errors.New("not found")
+	}
+
+	if _, err := DetectPatrolCLIVersion(executor); err == nil {
+		t.Fatal("expected error when no method detects a version")
+	}
+}
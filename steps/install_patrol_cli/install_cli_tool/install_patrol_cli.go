@@ -5,33 +5,52 @@ import (
 
 	"patrol_install/commands"
 	constants "patrol_install/steps/build/constants"
+	cli_constraint "patrol_install/steps/install_patrol_cli/cli_constraint"
+	cli_source "patrol_install/steps/install_patrol_cli/cli_source"
 	"patrol_install/utils/exec"
 	print "patrol_install/utils/print"
 )
 
-var patrolInstall = commands.PatrolInstall
-
 type CommandExecutor func(cmd commands.Command) (string, error)
 
-// InstallPatrolCLI installs the Patrol CLI, using a custom version if provided.
+// InstallPatrolCLI installs the Patrol CLI from the configured source, using a custom version or constraint if provided.
 // The executor parameter allows for dependency injection in tests. Pass nil to use the default executor.
 func InstallPatrolCLI(executor CommandExecutor) (string, error) {
-	customVersion := os.Getenv(constants.CustomPatrolCLIVersion)
+	constraint, err := cli_constraint.Parse(os.Getenv(constants.CustomPatrolCLIVersion))
+	if err != nil {
+		return "", err
+	}
+
+	source, err := cli_source.FromEnv()
+	if err != nil {
+		return "", err
+	}
+
+	retries, err := RetriesFromEnv()
+	if err != nil {
+		return "", err
+	}
 
-	if customVersion == "" {
+	switch {
+	case !source.IsHosted():
+		print.Action("Installing Patrol CLI from " + source.Description())
+		if constraint != nil {
+			print.Warning("Version " + constraint.Raw + " is only verified after activation for " + source.Kind + " sources.")
+		}
+	case constraint == nil:
 		print.Warning("Version was not provided. Using the latest version.")
-	} else {
-		print.Action("Installing custom version provided: " + customVersion)
+	default:
+		print.Action("Installing custom version provided: " + constraint.PubArgument())
 	}
 
-	installCmd := buildInstallCommand(customVersion)
+	installCmd := buildInstallCommand(source, constraint)
 
 	cmdExecutor := executor
 	if cmdExecutor == nil {
-		cmdExecutor = exec.Command
+		cmdExecutor = exec.CombinedCommand
 	}
 
-	output, err := cmdExecutor(installCmd)
+	output, err := runWithRetry(installCmd, cmdExecutor, retries)
 	if err != nil {
 		return output, err
 	}
@@ -40,10 +59,10 @@ func InstallPatrolCLI(executor CommandExecutor) (string, error) {
 	return output, nil
 }
 
-// buildInstallCommand returns the appropriate Command struct based on the version.
-func buildInstallCommand(version string) commands.Command {
-	if version == "" {
-		return patrolInstall
+// buildInstallCommand returns the appropriate Command struct based on the source and version constraint.
+func buildInstallCommand(source *cli_source.CLISource, constraint *cli_constraint.CLIConstraint) commands.Command {
+	if constraint == nil {
+		return source.ActivateCommand("")
 	}
-	return patrolInstall.CopyWith(nil, append(patrolInstall.Args, version))
+	return source.ActivateCommand(constraint.PubArgument())
 }
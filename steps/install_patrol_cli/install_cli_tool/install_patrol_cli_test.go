@@ -78,3 +78,33 @@ func TestInstallPatrolCLI_Error(t *testing.T) {
 		t.Errorf("expected empty output, got %q", output)
 	}
 }
+
+func TestInstallPatrolCLI_ConstraintPassedAsSingleArgument(t *testing.T) {
+	t.Setenv("CUSTOM_PATROL_CLI_VERSION", ">=3.7.0, <4.0.0")
+	var got commands.Command
+	executor := func(cmd commands.Command) (string, error) {
+		got = cmd
+		return "", nil
+	}
+	if _, err := InstallPatrolCLI(executor); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(got.Args) == 0 || got.Args[len(got.Args)-1] != ">=3.7.0 <4.0.0" {
+		t.Errorf("expected normalized constraint as last arg, got %v", got.Args)
+	}
+}
+
+func TestInstallPatrolCLI_MalformedConstraintFailsBeforeExecuting(t *testing.T) {
+	t.Setenv("CUSTOM_PATROL_CLI_VERSION", "~3.9")
+	called := false
+	executor := func(cmd commands.Command) (string, error) {
+		called = true
+		return "", nil
+	}
+	if _, err := InstallPatrolCLI(executor); err == nil {
+		t.Fatal("expected error for malformed constraint, got nil")
+	}
+	if called {
+		t.Error("executor should not be called for a malformed constraint")
+	}
+}
@@ -0,0 +1,112 @@
+package install_cli_tool
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"patrol_install/commands"
+	constants "patrol_install/steps/build/constants"
+	print "patrol_install/utils/print"
+)
+
+const (
+	defaultInstallRetries = 2
+	baseRetryDelay        = 2 * time.Second
+	maxRetryDelay         = 30 * time.Second
+)
+
+// transientInstallErrors matches pub output caused by network hiccups rather than a bad request.
+var transientInstallErrors = []*regexp.Regexp{
+	regexp.MustCompile(`(?i)socketexception`),
+	regexp.MustCompile(`(?i)got socket error`),
+	regexp.MustCompile(`(?i)handshakeexception`),
+	regexp.MustCompile(`(?i)tls error`),
+	regexp.MustCompile(`(?i)timeoutexception|timed out`),
+	regexp.MustCompile(`(?i)failed host lookup`),
+	regexp.MustCompile(`(?i)connection (closed|reset|refused)`),
+	regexp.MustCompile(`(?i)network is unreachable`),
+	regexp.MustCompile(`(?i)\b(http|status|error)( code)?:? ?(500|502|503|504)\b`),
+	regexp.MustCompile(`(?i)server error`),
+}
+
+// sleep is swapped in tests to avoid waiting between attempts.
+var sleep = time.Sleep
+
+// jitter returns a random delay in [delay/2, delay] so parallel builds don't retry in lockstep.
+var jitter = func(delay time.Duration) time.Duration {
+	half := int64(delay / 2)
+	if half <= 0 {
+		return delay
+	}
+	return time.Duration(half + rand.Int64N(half+1))
+}
+
+// RetriesFromEnv returns the number of retries allowed for transient install failures.
+func RetriesFromEnv() (int, error) {
+	raw := strings.TrimSpace(os.Getenv(constants.InstallRetries))
+	if raw == "" {
+		return defaultInstallRetries, nil
+	}
+	retries, err := strconv.Atoi(raw)
+	if err != nil || retries < 0 {
+		return 0, fmt.Errorf("invalid %s %q: expected a non-negative number", constants.InstallRetries, raw)
+	}
+	return retries, nil
+}
+
+// runWithRetry executes the command, retrying up to retries times when the failure looks transient.
+func runWithRetry(cmd commands.Command, executor CommandExecutor, retries int) (string, error) {
+	attempts := retries + 1
+	for attempt := 1; ; attempt++ {
+		print.Action(fmt.Sprintf("Install attempt %d/%d", attempt, attempts))
+
+		output, err := executor(cmd)
+		if err == nil {
+			return output, nil
+		}
+
+		if attempt == attempts {
+			return output, err
+		}
+		if !isTransientFailure(output, err) {
+			print.Error("Install failed with a non-transient error, not retrying.")
+			return output, err
+		}
+
+		delay := jitter(backoffDelay(attempt))
+		print.Warning(fmt.Sprintf("Install attempt %d failed with a transient error: %s. Retrying in %s...",
+			attempt, err.Error(), delay.Round(time.Millisecond)))
+		sleep(delay)
+	}
+}
+
+// isTransientFailure reports whether the command output or error matches a known transient network error.
+func isTransientFailure(output string, err error) bool {
+	text := output
+	if err != nil {
+		text += "\n" + err.Error()
+	}
+	for _, pattern := range transientInstallErrors {
+		if pattern.MatchString(text) {
+			return true
+		}
+	}
+	return false
+}
+
+// backoffDelay doubles the base delay for every failed attempt, capped at maxRetryDelay.
+func backoffDelay(attempt int) time.Duration {
+	delay := baseRetryDelay
+	for i := 1; i < attempt; i++ {
+		delay *= 2
+		if delay >= maxRetryDelay {
+			return maxRetryDelay
+		}
+	}
+	return delay
+}
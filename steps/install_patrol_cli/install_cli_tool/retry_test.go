@@ -0,0 +1,140 @@
+package install_cli_tool
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"patrol_install/commands"
+)
+
+// flakyExecutor fails with the given output until it has been called failures times.
+type flakyExecutor struct {
+	failures int
+	output   string
+	calls    int
+}
+
+func (f *flakyExecutor) run(cmd commands.Command) (string, error) {
+	f.calls++
+	if f.calls <= f.failures {
+		return f.output, errors.New("exit status 69")
+	}
+	return "Activated patrol_cli 4.0.1.", nil
+}
+
+func stubRetryTiming(t *testing.T) *[]time.Duration {
+	delays := []time.Duration{}
+	originalSleep := sleep
+	originalJitter := jitter
+	sleep = func(d time.Duration) { delays = append(delays, d) }
+	jitter = func(d time.Duration) time.Duration { return d }
+	t.Cleanup(func() {
+		sleep = originalSleep
+		jitter = originalJitter
+	})
+	return &delays
+}
+
+func TestRunWithRetry_RetriesTransientFailures(t *testing.T) {
+	// GIVEN an executor that fails twice with a network error
+	delays := stubRetryTiming(t)
+	executor := &flakyExecutor{failures: 2, output: "Got socket error trying to find package patrol_cli at https://pub.dev."}
+
+	// WHEN installing with two retries
+	output, err := runWithRetry(commands.PatrolInstall, executor.run, 2)
+
+	// THEN the third attempt succeeds after exponential backoff
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if output != "Activated patrol_cli 4.0.1." {
+		t.Errorf("unexpected output %q", output)
+	}
+	if executor.calls != 3 {
+		t.Errorf("expected 3 attempts, got %d", executor.calls)
+	}
+	if len(*delays) != 2 || (*delays)[0] != baseRetryDelay || (*delays)[1] != 2*baseRetryDelay {
+		t.Errorf("unexpected backoff delays %v", *delays)
+	}
+}
+
+func TestRunWithRetry_DoesNotRetryPermanentFailures(t *testing.T) {
+	// GIVEN an executor that fails because the version does not exist
+	delays := stubRetryTiming(t)
+	executor := &flakyExecutor{failures: 5, output: "Package patrol_cli has no versions that match 9.9.9."}
+
+	// WHEN installing with retries
+	_, err := runWithRetry(commands.PatrolInstall, executor.run, 3)
+
+	// THEN it fails after a single attempt
+	if err == nil {
+		t.Fatal("expected error, got nil")
+	}
+	if executor.calls != 1 {
+		t.Errorf("expected 1 attempt, got %d", executor.calls)
+	}
+	if len(*delays) != 0 {
+		t.Errorf("expected no backoff, got %v", *delays)
+	}
+}
+
+func TestRunWithRetry_GivesUpAfterRetries(t *testing.T) {
+	// GIVEN an executor that keeps failing with a transient error
+	stubRetryTiming(t)
+	executor := &flakyExecutor{failures: 10, output: "SocketException: Connection reset by peer"}
+
+	// WHEN installing with one retry
+	_, err := runWithRetry(commands.PatrolInstall, executor.run, 1)
+
+	// THEN the last error is returned after two attempts
+	if err == nil {
+		t.Fatal("expected error, got nil")
+	}
+	if executor.calls != 2 {
+		t.Errorf("expected 2 attempts, got %d", executor.calls)
+	}
+}
+
+func TestIsTransientFailure(t *testing.T) {
+	tests := []struct {
+		output string
+		want   bool
+	}{
+		{output: "Failed host lookup: 'pub.dev'", want: true},
+		{output: "HandshakeException: Connection terminated during handshake", want: true},
+		{output: "TimeoutException after 0:00:30.000000: Future not completed", want: true},
+		{output: "Got error code 503 from pub.dev", want: true},
+		{output: "Could not find package patrol_clii at https://pub.dev.", want: false},
+		{output: "The current Dart SDK version is 2.19.0. Because patrol_cli requires SDK version >=3.0.0", want: false},
+	}
+
+	for _, tt := range tests {
+		if got := isTransientFailure(tt.output, errors.New("exit status 1")); got != tt.want {
+			t.Errorf("isTransientFailure(%q) = %v, want %v", tt.output, got, tt.want)
+		}
+	}
+}
+
+func TestBackoffDelay_IsCapped(t *testing.T) {
+	if got := backoffDelay(10); got != maxRetryDelay {
+		t.Errorf("backoffDelay(10) = %s, want %s", got, maxRetryDelay)
+	}
+}
+
+func TestRetriesFromEnv(t *testing.T) {
+	t.Setenv("INSTALL_RETRIES", "")
+	if got, err := RetriesFromEnv(); err != nil || got != defaultInstallRetries {
+		t.Errorf("expected default retries, got %d, %v", got, err)
+	}
+
+	t.Setenv("INSTALL_RETRIES", "4")
+	if got, err := RetriesFromEnv(); err != nil || got != 4 {
+		t.Errorf("expected 4 retries, got %d, %v", got, err)
+	}
+
+	t.Setenv("INSTALL_RETRIES", "-1")
+	if _, err := RetriesFromEnv(); err == nil {
+		t.Error("expected error for negative retries")
+	}
+}
@@ -1,37 +1,119 @@
 package install_patrol_cli
 
 import (
-	v "github.com/Masterminds/semver/v3"
+	"fmt"
 
+	cli_constraint "patrol_install/steps/install_patrol_cli/cli_constraint"
+	cli_source "patrol_install/steps/install_patrol_cli/cli_source"
+	get_cli_version "patrol_install/steps/install_patrol_cli/get_cli_version"
 	"patrol_install/utils/print"
 )
 
 type Installer interface {
-	GetPatrolCLIVersion() (*v.Version, error)
+	GetPatrolCLIVersion() (*get_cli_version.Detection, error)
 	InstallPatrolCLI() error
+	// RequestedCLIVersion returns the version or constraint requested by the user, empty for latest.
+	RequestedCLIVersion() string
+	// RequestedCLISource returns the validated source patrol_cli is activated from.
+	RequestedCLISource() (*cli_source.CLISource, error)
+	// EnsurePubBinOnPath makes executables activated with `dart pub global activate` callable.
+	EnsurePubBinOnPath() error
+	// WarnIfPatrolShadowed warns when `patrol` on PATH is not the activated Patrol CLI.
+	WarnIfPatrolShadowed()
 }
 
-func Run(installer Installer) (*v.Version, error) {
+func Run(installer Installer) (*get_cli_version.Detection, error) {
+	// Validate the requested version before touching the network
+	constraint, err := cli_constraint.Parse(installer.RequestedCLIVersion())
+	if err != nil {
+		print.Error("❌ " + err.Error())
+		return nil, err
+	}
+
+	source, err := installer.RequestedCLISource()
+	if err != nil {
+		print.Error("❌ " + err.Error())
+		return nil, err
+	}
+
+	if err := installer.EnsurePubBinOnPath(); err != nil {
+		print.Error("❌ " + err.Error())
+		return nil, err
+	}
+
+	detection, err := ensureInstalled(installer, source, constraint)
+	if err != nil {
+		return nil, err
+	}
+
+	// Only now is the activated executable in place, so check what later steps will resolve
+	installer.WarnIfPatrolShadowed()
+	return detection, nil
+}
+
+// ensureInstalled activates the CLI from source unless a hosted install already satisfies the constraint.
+func ensureInstalled(installer Installer, source *cli_source.CLISource, constraint *cli_constraint.CLIConstraint) (*get_cli_version.Detection, error) {
+	// Git and path sources can change without a version bump, so they are always re-activated
+	if !source.IsHosted() {
+		print.StepInitiated("--- Activating Patrol CLI from " + source.Description() + " ---")
+		detection, err := installAndVerify(installer, constraint)
+		if err != nil {
+			return nil, err
+		}
+
+		print.StepCompleted("✅ PATROL CLI activated from " + source.Kind + ". Version: " + detection.Version.String() + "\n")
+		return detection, nil
+	}
+
 	print.StepInitiated("--- Checking if Patrol CLI is already installed ---")
 
-	version, err := installer.GetPatrolCLIVersion()
+	detection, err := installer.GetPatrolCLIVersion()
 	if err != nil {
 		print.Warning("CLI is not installed, attempting installation...")
-		if err := installer.InstallPatrolCLI(); err != nil {
-			print.Error("❌ Installation failed: " + err.Error())
+		detection, err = installAndVerify(installer, constraint)
+		if err != nil {
 			return nil, err
 		}
 
-		version, err = installer.GetPatrolCLIVersion()
+		print.StepCompleted("✅ PATROL CLI installed successfully. Version: " + detection.Version.String() + "\n")
+		return detection, nil
+	}
+
+	if constraint != nil && !constraint.Check(detection.Version) {
+		print.Warning(fmt.Sprintf("Installed Patrol CLI %s does not match the requested version %s, reinstalling...",
+			detection.Version.String(), constraint.Raw))
+		previous := detection.Version
+		detection, err = installAndVerify(installer, constraint)
 		if err != nil {
-			print.Error("❌ Failed to verify version after install: " + err.Error())
 			return nil, err
 		}
 
-		print.StepCompleted("✅ PATROL CLI installed successfully. Version: " + version.String() + "\n")
-		return version, nil
+		print.StepCompleted(fmt.Sprintf("✅ PATROL CLI changed from %s to %s\n", previous.String(), detection.Version.String()))
+		return detection, nil
+	}
+
+	print.StepCompleted("✅ Tool already installed. Version: " + detection.Version.String() + "\n")
+	return detection, nil
+}
+
+// installAndVerify installs the CLI and checks that the resulting version satisfies the constraint, if any.
+func installAndVerify(installer Installer, constraint *cli_constraint.CLIConstraint) (*get_cli_version.Detection, error) {
+	if err := installer.InstallPatrolCLI(); err != nil {
+		print.Error("❌ Installation failed: " + err.Error())
+		return nil, err
+	}
+
+	detection, err := installer.GetPatrolCLIVersion()
+	if err != nil {
+		print.Error("❌ Failed to verify version after install: " + err.Error())
+		return nil, err
+	}
+
+	if constraint != nil && !constraint.Check(detection.Version) {
+		err := fmt.Errorf("installed Patrol CLI %s does not satisfy the requested version %s", detection.Version.String(), constraint.Raw)
+		print.Error("❌ " + err.Error())
+		return nil, err
 	}
 
-	print.StepCompleted("✅ Tool already installed. Version: " + version.String() + "\n")
-	return version, nil
+	return detection, nil
 }
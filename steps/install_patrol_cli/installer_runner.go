@@ -1,15 +1,18 @@
 package install_patrol_cli
 
 import (
+	"os"
+
+	constants "patrol_install/steps/build/constants"
+	cli_source "patrol_install/steps/install_patrol_cli/cli_source"
 	get_cli_version "patrol_install/steps/install_patrol_cli/get_cli_version"
 	install_cli_tool "patrol_install/steps/install_patrol_cli/install_cli_tool"
-
-	v "github.com/Masterminds/semver/v3"
+	pub_bin_path "patrol_install/steps/install_patrol_cli/pub_bin_path"
 )
 
 type InstallerRunner struct{}
 
-func (p *InstallerRunner) GetPatrolCLIVersion() (*v.Version, error) {
+func (p *InstallerRunner) GetPatrolCLIVersion() (*get_cli_version.Detection, error) {
 	return get_cli_version.GetPatrolCLIVersion()
 }
 
@@ -17,3 +20,19 @@ func (p *InstallerRunner) InstallPatrolCLI() error {
 	_, err := install_cli_tool.InstallPatrolCLI(nil)
 	return err
 }
+
+func (p *InstallerRunner) RequestedCLIVersion() string {
+	return os.Getenv(constants.CustomPatrolCLIVersion)
+}
+
+func (p *InstallerRunner) RequestedCLISource() (*cli_source.CLISource, error) {
+	return cli_source.FromEnv()
+}
+
+func (p *InstallerRunner) EnsurePubBinOnPath() error {
+	return pub_bin_path.EnsureOnPath()
+}
+
+func (p *InstallerRunner) WarnIfPatrolShadowed() {
+	pub_bin_path.WarnIfShadowed()
+}
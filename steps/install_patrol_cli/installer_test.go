@@ -0,0 +1,258 @@
+package install_patrol_cli
+
+import (
+	"errors"
+	"testing"
+
+	v "github.com/Masterminds/semver/v3"
+
+	cli_source "patrol_install/steps/install_patrol_cli/cli_source"
+	get_cli_version "patrol_install/steps/install_patrol_cli/get_cli_version"
+)
+
+type installerStub struct {
+	versions     []*v.Version
+	versionErr   error
+	installErr   error
+	requested    string
+	source       *cli_source.CLISource
+	sourceErr    error
+	pathCalls    int
+	shadowCalls  int
+	installCalls int
+	versionCalls int
+}
+
+func (s *installerStub) GetPatrolCLIVersion() (*get_cli_version.Detection, error) {
+	s.versionCalls++
+	if s.versionErr != nil && s.installCalls == 0 {
+		return nil, s.versionErr
+	}
+	index := s.versionCalls - 1
+	if index >= len(s.versions) {
+		index = len(s.versions) - 1
+	}
+	return &get_cli_version.Detection{Version: s.versions[index], Method: get_cli_version.MethodPubGlobalList}, nil
+}
+
+func (s *installerStub) InstallPatrolCLI() error {
+	s.installCalls++
+	return s.installErr
+}
+
+func (s *installerStub) RequestedCLIVersion() string {
+	return s.requested
+}
+
+func (s *installerStub) EnsurePubBinOnPath() error {
+	s.pathCalls++
+	return nil
+}
+
+func (s *installerStub) WarnIfPatrolShadowed() {
+	s.shadowCalls++
+}
+
+func (s *installerStub) RequestedCLISource() (*cli_source.CLISource, error) {
+	if s.sourceErr != nil {
+		return nil, s.sourceErr
+	}
+	if s.source == nil {
+		return &cli_source.CLISource{Kind: cli_source.SourceHosted}, nil
+	}
+	return s.source, nil
+}
+
+func TestRun_AlreadyInstalledWithoutRequestedVersion(t *testing.T) {
+	// GIVEN an installed CLI and no requested version
+	stub := &installerStub{versions: []*v.Version{v.MustParse("4.0.1")}}
+
+	// WHEN running the installer
+	detection, err := Run(stub)
+
+	// THEN the installed version is kept
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if stub.pathCalls != 1 {
+		t.Fatalf("expected the pub bin dir to be added to PATH, got %d calls", stub.pathCalls)
+	}
+	if stub.installCalls != 0 {
+		t.Fatalf("expected no installation, got %d", stub.installCalls)
+	}
+	if !detection.Version.Equal(v.MustParse("4.0.1")) {
+		t.Fatalf("expected 4.0.1, got %s", detection.Version)
+	}
+}
+
+func TestRun_AlreadyInstalledMatchingRequestedVersion(t *testing.T) {
+	// GIVEN an installed CLI matching the requested version
+	stub := &installerStub{versions: []*v.Version{v.MustParse("3.9.0")}, requested: "3.9.0"}
+
+	// WHEN running the installer
+	_, err := Run(stub)
+
+	// THEN nothing is reinstalled
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if stub.installCalls != 0 {
+		t.Fatalf("expected no installation, got %d", stub.installCalls)
+	}
+}
+
+func TestRun_ReinstallsWhenRequestedVersionDiffers(t *testing.T) {
+	// GIVEN a cached CLI that differs from the requested version
+	stub := &installerStub{
+		versions:  []*v.Version{v.MustParse("4.0.1"), v.MustParse("3.9.0")},
+		requested: "3.9.0",
+	}
+
+	// WHEN running the installer
+	detection, err := Run(stub)
+
+	// THEN the requested version is activated
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if stub.installCalls != 1 {
+		t.Fatalf("expected one installation, got %d", stub.installCalls)
+	}
+	if !detection.Version.Equal(v.MustParse("3.9.0")) {
+		t.Fatalf("expected 3.9.0, got %s", detection.Version)
+	}
+}
+
+func TestRun_InstallsWhenMissing(t *testing.T) {
+	// GIVEN no installed CLI
+	stub := &installerStub{
+		versions:   []*v.Version{v.MustParse("4.0.1")},
+		versionErr: errors.New("patrol: command not found"),
+	}
+
+	// WHEN running the installer
+	detection, err := Run(stub)
+
+	// THEN the CLI is installed and verified
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if stub.installCalls != 1 {
+		t.Fatalf("expected one installation, got %d", stub.installCalls)
+	}
+	if detection == nil || detection.Method != get_cli_version.MethodPubGlobalList {
+		t.Fatalf("expected a detection after install, got %+v", detection)
+	}
+	if stub.shadowCalls != 1 {
+		t.Fatalf("expected PATH to be checked after activation, got %d checks", stub.shadowCalls)
+	}
+}
+
+func TestRun_ReturnsInstallError(t *testing.T) {
+	// GIVEN a differing version and a failing installation
+	stub := &installerStub{
+		versions:   []*v.Version{v.MustParse("4.0.1")},
+		requested:  "3.9.0",
+		installErr: errors.New("install failed"),
+	}
+
+	// WHEN running the installer
+	_, err := Run(stub)
+
+	// THEN the installation error is returned
+	if err == nil || err.Error() != "install failed" {
+		t.Fatalf("expected install error, got %v", err)
+	}
+	if stub.shadowCalls != 0 {
+		t.Fatalf("expected no PATH check after a failed installation, got %d", stub.shadowCalls)
+	}
+}
+
+func TestRun_MalformedRequestFailsBeforeAnyCommand(t *testing.T) {
+	// GIVEN a malformed requested version
+	stub := &installerStub{versions: []*v.Version{v.MustParse("4.0.1")}, requested: "3.9"}
+
+	// WHEN running the installer
+	_, err := Run(stub)
+
+	// THEN it fails without checking or installing anything
+	if err == nil {
+		t.Fatal("expected error, got nil")
+	}
+	if stub.versionCalls != 0 || stub.installCalls != 0 {
+		t.Fatalf("expected no calls, got version=%d install=%d", stub.versionCalls, stub.installCalls)
+	}
+}
+
+func TestRun_KeepsInstalledVersionSatisfyingConstraint(t *testing.T) {
+	// GIVEN an installed CLI inside the requested range
+	stub := &installerStub{versions: []*v.Version{v.MustParse("3.11.0")}, requested: ">=3.7.0 <4.0.0"}
+
+	// WHEN running the installer
+	_, err := Run(stub)
+
+	// THEN nothing is reinstalled
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if stub.installCalls != 0 {
+		t.Fatalf("expected no installation, got %d", stub.installCalls)
+	}
+}
+
+func TestRun_FailsWhenInstalledVersionDoesNotSatisfyConstraint(t *testing.T) {
+	// GIVEN an installation that still resolves outside the constraint
+	stub := &installerStub{
+		versions:  []*v.Version{v.MustParse("4.0.1"), v.MustParse("4.0.1")},
+		requested: "^3.9.0",
+	}
+
+	// WHEN running the installer
+	_, err := Run(stub)
+
+	// THEN the post-install verification fails
+	if err == nil {
+		t.Fatal("expected error, got nil")
+	}
+	if stub.installCalls != 1 {
+		t.Fatalf("expected one installation, got %d", stub.installCalls)
+	}
+}
+
+func TestRun_GitSourceAlwaysActivates(t *testing.T) {
+	// GIVEN an installed CLI and a git source
+	stub := &installerStub{
+		versions: []*v.Version{v.MustParse("4.0.1")},
+		source:   &cli_source.CLISource{Kind: cli_source.SourceGit, GitURL: "https://example.com/patrol.git"},
+	}
+
+	// WHEN running the installer
+	_, err := Run(stub)
+
+	// THEN the fork is activated even though a CLI is already installed
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if stub.installCalls != 1 {
+		t.Fatalf("expected one installation, got %d", stub.installCalls)
+	}
+}
+
+func TestRun_InvalidSourceFailsBeforeAnyCommand(t *testing.T) {
+	// GIVEN an invalid source configuration
+	stub := &installerStub{
+		versions:  []*v.Version{v.MustParse("4.0.1")},
+		sourceErr: errors.New("PATROL_CLI_GIT_URL is required"),
+	}
+
+	// WHEN running the installer
+	_, err := Run(stub)
+
+	// THEN it fails without checking or installing anything
+	if err == nil {
+		t.Fatal("expected error, got nil")
+	}
+	if stub.versionCalls != 0 || stub.installCalls != 0 {
+		t.Fatalf("expected no calls, got version=%d install=%d", stub.versionCalls, stub.installCalls)
+	}
+}
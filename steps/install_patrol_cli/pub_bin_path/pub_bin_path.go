@@ -0,0 +1,136 @@
+package pub_bin_path
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	export_artifacts_utils "patrol_install/steps/export_artifacts/utils"
+	print "patrol_install/utils/print"
+)
+
+const (
+	pathEnvKey     = "PATH"
+	pubCacheEnvKey = "PUB_CACHE"
+)
+
+// BinDir returns the directory `dart pub global activate` puts executables into,
+// honouring PUB_CACHE and falling back to the platform default cache location.
+func BinDir() (string, error) {
+	if pubCache := strings.TrimSpace(os.Getenv(pubCacheEnvKey)); pubCache != "" {
+		return filepath.Join(pubCache, "bin"), nil
+	}
+
+	if runtime.GOOS == "windows" {
+		if localAppData := os.Getenv("LOCALAPPDATA"); localAppData != "" {
+			return filepath.Join(localAppData, "Pub", "Cache", "bin"), nil
+		}
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to locate the pub cache: %w", err)
+	}
+	return filepath.Join(home, ".pub-cache", "bin"), nil
+}
+
+// EnsureOnPath prepends the pub bin directory to PATH for this process and its children
+// and exports the updated PATH for later steps.
+func EnsureOnPath() error {
+	binDir, err := BinDir()
+	if err != nil {
+		return err
+	}
+
+	updatedPath, changed := PrependToPath(os.Getenv(pathEnvKey), binDir)
+	if !changed {
+		return nil
+	}
+
+	if err := os.Setenv(pathEnvKey, updatedPath); err != nil {
+		return fmt.Errorf("failed to update PATH: %w", err)
+	}
+	print.Action("Added " + binDir + " to PATH")
+
+	if err := export_artifacts_utils.ExportEnv(pathEnvKey, updatedPath); err != nil {
+		print.Warning("Could not export PATH for later steps: " + err.Error())
+	}
+	return nil
+}
+
+// WarnIfShadowed warns when `patrol` on the current PATH, the one later steps inherit,
+// does not resolve to the executable activated into the pub bin directory.
+// It is meant to run after activation, once EnsureOnPath has updated PATH.
+func WarnIfShadowed() {
+	binDir, err := BinDir()
+	if err != nil {
+		print.Warning("Could not check which patrol executable is on PATH: " + err.Error())
+		return
+	}
+
+	if shadowing := FindShadowingPatrol(os.Getenv(pathEnvKey), binDir); shadowing != "" {
+		print.Warning(fmt.Sprintf("⚠️ %s is the first patrol on PATH and shadows the Patrol CLI activated into %s.",
+			shadowing, binDir))
+	}
+}
+
+// PrependToPath returns pathEnv with dir in front, unless dir is already its first entry.
+func PrependToPath(pathEnv, dir string) (string, bool) {
+	entries := filepath.SplitList(pathEnv)
+	if len(entries) > 0 && samePath(entries[0], dir) {
+		return pathEnv, false
+	}
+
+	updated := []string{dir}
+	for _, entry := range entries {
+		if entry != "" && !samePath(entry, dir) {
+			updated = append(updated, entry)
+		}
+	}
+	return strings.Join(updated, string(os.PathListSeparator)), true
+}
+
+// FindShadowingPatrol returns the patrol executable pathEnv resolves to when it is not the one in binDir,
+// or an empty string when the one in binDir would be picked up or no patrol is found at all.
+func FindShadowingPatrol(pathEnv, binDir string) string {
+	for _, entry := range filepath.SplitList(pathEnv) {
+		if entry == "" {
+			continue
+		}
+		for _, name := range patrolExecutableNames() {
+			candidate := filepath.Join(entry, name)
+			if !isExecutable(candidate) {
+				continue
+			}
+			if samePath(entry, binDir) {
+				return ""
+			}
+			return candidate
+		}
+	}
+	return ""
+}
+
+func patrolExecutableNames() []string {
+	if runtime.GOOS == "windows" {
+		return []string{"patrol.bat", "patrol.exe"}
+	}
+	return []string{"patrol"}
+}
+
+func isExecutable(path string) bool {
+	info, err := os.Stat(path)
+	if err != nil || info.IsDir() {
+		return false
+	}
+	if runtime.GOOS == "windows" {
+		return true
+	}
+	return info.Mode().Perm()&0111 != 0
+}
+
+func samePath(a, b string) bool {
+	return filepath.Clean(a) == filepath.Clean(b)
+}
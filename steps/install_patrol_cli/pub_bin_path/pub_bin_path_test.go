@@ -0,0 +1,141 @@
+package pub_bin_path
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	export_artifacts_utils "patrol_install/steps/export_artifacts/utils"
+)
+
+type exporterSpy struct {
+	exported map[string]string
+}
+
+func (s *exporterSpy) Export(key, value string) error {
+	s.exported[key] = value
+	return nil
+}
+
+func writeExecutable(t *testing.T, dir, name string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte("#!/bin/sh\n"), 0755); err != nil {
+		t.Fatalf("failed to write %s: %v", path, err)
+	}
+	return path
+}
+
+func joinPath(entries ...string) string {
+	return strings.Join(entries, string(os.PathListSeparator))
+}
+
+func TestBinDir_UsesPubCache(t *testing.T) {
+	t.Setenv("PUB_CACHE", "/tmp/custom-cache")
+
+	got, err := BinDir()
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if got != filepath.Join("/tmp/custom-cache", "bin") {
+		t.Errorf("BinDir() = %q, want %q", got, "/tmp/custom-cache/bin")
+	}
+}
+
+func TestBinDir_DefaultsToHomePubCache(t *testing.T) {
+	t.Setenv("PUB_CACHE", "")
+	t.Setenv("HOME", "/home/ci")
+
+	got, err := BinDir()
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if got != filepath.Join("/home/ci", ".pub-cache", "bin") {
+		t.Errorf("BinDir() = %q", got)
+	}
+}
+
+func TestPrependToPath(t *testing.T) {
+	tests := []struct {
+		name        string
+		pathEnv     string
+		wantPath    string
+		wantChanged bool
+	}{
+		{name: "missing", pathEnv: joinPath("/usr/bin", "/bin"), wantPath: joinPath("/pub/bin", "/usr/bin", "/bin"), wantChanged: true},
+		{name: "already_first", pathEnv: joinPath("/pub/bin", "/usr/bin"), wantPath: joinPath("/pub/bin", "/usr/bin"), wantChanged: false},
+		{name: "moved_to_front", pathEnv: joinPath("/usr/bin", "/pub/bin/"), wantPath: joinPath("/pub/bin", "/usr/bin"), wantChanged: true},
+		{name: "empty", pathEnv: "", wantPath: "/pub/bin", wantChanged: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, changed := PrependToPath(tt.pathEnv, "/pub/bin")
+			if got != tt.wantPath || changed != tt.wantChanged {
+				t.Errorf("PrependToPath(%q) = (%q, %v), want (%q, %v)", tt.pathEnv, got, changed, tt.wantPath, tt.wantChanged)
+			}
+		})
+	}
+}
+
+func TestFindShadowingPatrol(t *testing.T) {
+	// GIVEN a homebrew patrol and the pub cache patrol
+	brewDir := t.TempDir()
+	pubDir := t.TempDir()
+	brewPatrol := writeExecutable(t, brewDir, "patrol")
+	writeExecutable(t, pubDir, "patrol")
+
+	// WHEN the other patrol comes first
+	// THEN it is reported as shadowing
+	if got := FindShadowingPatrol(joinPath(brewDir, pubDir), pubDir); got != brewPatrol {
+		t.Errorf("expected %q to shadow, got %q", brewPatrol, got)
+	}
+
+	// WHEN the pub cache comes first
+	// THEN nothing is reported
+	if got := FindShadowingPatrol(joinPath(pubDir, brewDir), pubDir); got != "" {
+		t.Errorf("expected no shadowing, got %q", got)
+	}
+}
+
+func TestFindShadowingPatrol_PubCacheFirstButEmpty(t *testing.T) {
+	// GIVEN the pub cache first on PATH but without a patrol executable
+	brewDir := t.TempDir()
+	pubDir := t.TempDir()
+	brewPatrol := writeExecutable(t, brewDir, "patrol")
+
+	// WHEN resolving patrol on the PATH later steps see
+	got := FindShadowingPatrol(joinPath(pubDir, brewDir), pubDir)
+
+	// THEN the patrol further down the PATH is reported
+	if got != brewPatrol {
+		t.Errorf("expected %q to shadow, got %q", brewPatrol, got)
+	}
+}
+
+func TestEnsureOnPath_PrependsAndExports(t *testing.T) {
+	// GIVEN a pub cache that is not on PATH
+	pubCache := t.TempDir()
+	t.Setenv("PUB_CACHE", pubCache)
+	t.Setenv("PATH", "/usr/bin")
+	spy := &exporterSpy{exported: map[string]string{}}
+	export_artifacts_utils.SetEnvExporter(spy)
+	t.Cleanup(func() {
+		export_artifacts_utils.SetEnvExporter(nil)
+	})
+
+	// WHEN ensuring the bin dir is on PATH
+	if err := EnsureOnPath(); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	// THEN PATH is updated for this process and exported
+	want := joinPath(filepath.Join(pubCache, "bin"), "/usr/bin")
+	if os.Getenv("PATH") != want {
+		t.Errorf("PATH = %q, want %q", os.Getenv("PATH"), want)
+	}
+	if spy.exported["PATH"] != want {
+		t.Errorf("exported PATH = %q, want %q", spy.exported["PATH"], want)
+	}
+}
@@ -0,0 +1,199 @@
+package junit_timings
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// TestTiming is the duration of a test case read from a JUnit XML report.
+type TestTiming struct {
+	// File is the file attribute of the test case or its suite, often empty.
+	File      string
+	ClassName string
+	Name      string
+	Seconds   float64
+}
+
+// ReadDir parses every .xml file under dir as a JUnit report. Files that aren't
+// valid JUnit reports are skipped, so the directory can hold other outputs too.
+func ReadDir(dir string) ([]TestTiming, error) {
+	var timings []TestTiming
+	err := filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() || !strings.EqualFold(filepath.Ext(path), ".xml") {
+			return nil
+		}
+
+		file, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+
+		parsed, err := Parse(file)
+		if err == nil {
+			timings = append(timings, parsed...)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read JUnit results from %s: %w", dir, err)
+	}
+	return timings, nil
+}
+
+// Parse reads the test cases of a JUnit report, with a <testsuites> or <testsuite> root.
+func Parse(reader io.Reader) ([]TestTiming, error) {
+	decoder := xml.NewDecoder(reader)
+	var timings []TestTiming
+	var suiteFiles []string
+	sawSuite := false
+
+	for {
+		token, err := decoder.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid JUnit XML: %w", err)
+		}
+
+		switch element := token.(type) {
+		case xml.StartElement:
+			switch element.Name.Local {
+			case "testsuite":
+				sawSuite = true
+				file := attr(element, "file")
+				if file == "" && len(suiteFiles) > 0 {
+					file = suiteFiles[len(suiteFiles)-1]
+				}
+				suiteFiles = append(suiteFiles, file)
+			case "testcase":
+				seconds, _ := strconv.ParseFloat(strings.TrimSpace(attr(element, "time")), 64)
+				file := attr(element, "file")
+				if file == "" && len(suiteFiles) > 0 {
+					file = suiteFiles[len(suiteFiles)-1]
+				}
+				timings = append(timings, TestTiming{
+					File:      file,
+					ClassName: attr(element, "classname"),
+					Name:      attr(element, "name"),
+					Seconds:   seconds,
+				})
+			}
+		case xml.EndElement:
+			if element.Name.Local == "testsuite" && len(suiteFiles) > 0 {
+				suiteFiles = suiteFiles[:len(suiteFiles)-1]
+			}
+		}
+	}
+
+	if !sawSuite {
+		return nil, fmt.Errorf("no <testsuite> found")
+	}
+	return timings, nil
+}
+
+// FileDurations sums the test case durations per test file. files are paths relative to the project,
+// e.g. patrol_test/flows/login_test.dart. A test case is matched to the file whose path appears in its
+// file attribute or, when it has none, its class name or name, with or without the test directory and
+// with / or . separators, as the Android and iOS runners report them. The most specific match wins;
+// unmatched cases are ignored.
+func FileDurations(timings []TestTiming, files []string, testDirectory string) map[string]float64 {
+	type fileKey struct {
+		file    string
+		pattern *regexp.Regexp
+		length  int
+	}
+
+	owners := map[string][]string{}
+	for _, file := range files {
+		for _, key := range keysFor(file, testDirectory) {
+			owners[key] = append(owners[key], file)
+		}
+	}
+
+	var keys []fileKey
+	for key, keyFiles := range owners {
+		// A file name shared by several directories can't tell them apart
+		if len(keyFiles) > 1 {
+			continue
+		}
+		pattern := regexp.MustCompile(`(^|[^A-Za-z0-9_])` + regexp.QuoteMeta(key) + `($|[^A-Za-z0-9_])`)
+		keys = append(keys, fileKey{file: keyFiles[0], pattern: pattern, length: len(key)})
+	}
+	// Longest keys first, so flows/login_test wins over login_test
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].length != keys[j].length {
+			return keys[i].length > keys[j].length
+		}
+		return keys[i].pattern.String() < keys[j].pattern.String()
+	})
+
+	match := func(text string) string {
+		text = strings.ReplaceAll(text, "\\", "/")
+		for _, key := range keys {
+			if key.pattern.MatchString(text) {
+				return key.file
+			}
+		}
+		return ""
+	}
+
+	durations := map[string]float64{}
+	for _, timing := range timings {
+		file := ""
+		if timing.File != "" {
+			file = match(timing.File)
+		}
+		if file == "" {
+			file = match(timing.ClassName + " " + timing.Name)
+		}
+		if file != "" {
+			durations[file] += timing.Seconds
+		}
+	}
+	return durations
+}
+
+// keysFor returns the ways a runner may name the file, e.g. for patrol_test/flows/login_test.dart:
+// patrol_test/flows/login_test, flows/login_test, flows.login_test and login_test.
+func keysFor(file, testDirectory string) []string {
+	withoutExt := strings.TrimSuffix(filepath.ToSlash(file), ".dart")
+	relative := strings.TrimPrefix(withoutExt, strings.TrimSuffix(filepath.ToSlash(testDirectory), "/")+"/")
+
+	keys := []string{withoutExt, relative, strings.ReplaceAll(relative, "/", "."), strings.ReplaceAll(withoutExt, "/", ".")}
+	if base := relative[strings.LastIndex(relative, "/")+1:]; base != relative {
+		keys = append(keys, base)
+	}
+
+	seen := map[string]bool{}
+	var unique []string
+	for _, key := range keys {
+		if key != "" && !seen[key] {
+			seen[key] = true
+			unique = append(unique, key)
+		}
+	}
+	return unique
+}
+
+func attr(element xml.StartElement, name string) string {
+	for _, attribute := range element.Attr {
+		if attribute.Name.Local == name {
+			return attribute.Value
+		}
+	}
+	return ""
+}
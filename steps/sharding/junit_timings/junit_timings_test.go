@@ -0,0 +1,108 @@
+package junit_timings
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const androidReport = `<?xml version="1.0" encoding="UTF-8"?>
+<testsuites>
+  <testsuite name="pl.leancode.patrol.example.MainActivityTest" tests="3" time="95.5">
+    <testcase classname="pl.leancode.patrol.example.MainActivityTest" name="login_test logs in" time="40.0"/>
+    <testcase classname="pl.leancode.patrol.example.MainActivityTest" name="flows.checkout_test pays" time="50.5"/>
+    <testcase classname="pl.leancode.patrol.example.MainActivityTest" name="unknown_test runs" time="5"/>
+  </testsuite>
+</testsuites>
+`
+
+const iosReport = `<testsuite name="RunnerUITests" file="patrol_test/login_test.dart">
+  <testcase classname="RunnerUITests" name="logs out" time="12.5"/>
+  <testcase classname="RunnerUITests" name="flows/checkout_test refunds" time="7"/>
+</testsuite>
+`
+
+func TestParse(t *testing.T) {
+	timings, err := Parse(strings.NewReader(iosReport))
+	if err != nil {
+		t.Fatalf("Parse() error: %v", err)
+	}
+	if len(timings) != 2 {
+		t.Fatalf("expected 2 test cases, got %+v", timings)
+	}
+	if timings[0].File != "patrol_test/login_test.dart" || timings[0].Seconds != 12.5 || timings[0].Name != "logs out" {
+		t.Errorf("unexpected timing %+v", timings[0])
+	}
+
+	if _, err := Parse(strings.NewReader("<html></html>")); err == nil {
+		t.Error("expected error for a non JUnit document")
+	}
+}
+
+func TestReadDir(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"android/report.xml": androidReport,
+		"ios/report.xml":     iosReport,
+		"notes.txt":          "not a report",
+		"broken.xml":         "<testsuite",
+	}
+	for path, content := range files {
+		full := filepath.Join(dir, path)
+		if err := os.MkdirAll(filepath.Dir(full), 0755); err != nil {
+			t.Fatalf("failed to create dir: %v", err)
+		}
+		if err := os.WriteFile(full, []byte(content), 0644); err != nil {
+			t.Fatalf("failed to write %s: %v", path, err)
+		}
+	}
+
+	timings, err := ReadDir(dir)
+	if err != nil {
+		t.Fatalf("ReadDir() error: %v", err)
+	}
+	if len(timings) != 5 {
+		t.Errorf("expected 5 test cases, got %d", len(timings))
+	}
+
+	if _, err := ReadDir(filepath.Join(dir, "missing")); err == nil {
+		t.Error("expected error for a missing directory")
+	}
+}
+
+func TestFileDurations(t *testing.T) {
+	// The file attribute of the iOS suite wins over the names of its test cases
+	androidTimings, _ := Parse(strings.NewReader(androidReport))
+	iosTimings, _ := Parse(strings.NewReader(iosReport))
+	timings := append(androidTimings, iosTimings...)
+
+	files := []string{
+		"patrol_test/login_test.dart",
+		"patrol_test/flows/checkout_test.dart",
+		"patrol_test/relogin_test.dart",
+	}
+	durations := FileDurations(timings, files, "patrol_test")
+
+	want := map[string]float64{
+		"patrol_test/login_test.dart":          59.5,
+		"patrol_test/flows/checkout_test.dart": 50.5,
+	}
+	if len(durations) != len(want) {
+		t.Fatalf("FileDurations() = %v, want %v", durations, want)
+	}
+	for file, seconds := range want {
+		if durations[file] != seconds {
+			t.Errorf("duration of %s = %v, want %v", file, durations[file], seconds)
+		}
+	}
+}
+
+func TestFileDurations_AmbiguousBaseName(t *testing.T) {
+	timings := []TestTiming{{Name: "login_test logs in", Seconds: 10}}
+	files := []string{"patrol_test/a/login_test.dart", "patrol_test/b/login_test.dart"}
+
+	if durations := FileDurations(timings, files, "patrol_test"); len(durations) != 0 {
+		t.Errorf("expected an ambiguous name to be ignored, got %v", durations)
+	}
+}
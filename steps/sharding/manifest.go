@@ -0,0 +1,52 @@
+package sharding
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+const (
+	ManifestPath       = "patrol/shard_manifest.json"
+	ManifestPathEnvKey = "PATROL_SHARD_MANIFEST_PATH"
+)
+
+// Manifest describes the shards built by the step, so later steps can run them in parallel.
+type Manifest struct {
+	Strategy   string          `json:"strategy"`
+	ShardCount int             `json:"shardCount"`
+	Platform   string          `json:"platform"`
+	Shards     []ManifestShard `json:"shards"`
+}
+
+type ManifestShard struct {
+	Index int `json:"index"`
+	// Target is the value passed to `patrol build --target`.
+	Target string   `json:"target"`
+	Files  []string `json:"files"`
+	Tests  int      `json:"tests"`
+	// PredictedDurationSeconds is the duration expected from previous runs, only set by by-timing.
+	PredictedDurationSeconds float64 `json:"predictedDurationSeconds,omitempty"`
+	// Platforms holds the status of each selected platform for this shard, as in the build report:
+	// succeeded, build_failed or export_failed.
+	Platforms map[string]string `json:"platforms"`
+	// AndroidArtifacts and IOSArtifacts are the export folders, empty when the platform wasn't exported.
+	AndroidArtifacts string `json:"androidArtifacts,omitempty"`
+	IOSArtifacts     string `json:"iosArtifacts,omitempty"`
+}
+
+// Write saves the manifest as indented JSON, creating the parent directory.
+func (m *Manifest) Write(path string) error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create folder %s: %w", filepath.Dir(path), err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
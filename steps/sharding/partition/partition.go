@@ -0,0 +1,156 @@
+package partition
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Sharding strategies accepted by SHARD_STRATEGY.
+const (
+	StrategyRoundRobin = "round-robin"
+	StrategyByFile     = "by-file"
+	StrategyByTiming   = "by-timing"
+)
+
+var Strategies = []string{StrategyRoundRobin, StrategyByFile, StrategyByTiming}
+
+// File is a test file to distribute, weighted by the number of tests it declares.
+type File struct {
+	Path  string
+	Tests int
+}
+
+// Shard is a group of test files built into one bundle.
+type Shard struct {
+	// Index is zero based and names the shard_<index> export folders.
+	Index int
+	Files []string
+	Tests int
+	// PredictedSeconds is the expected duration of the shard, only set by ByTiming.
+	PredictedSeconds float64
+}
+
+// IsStrategy reports whether the value is a known strategy.
+func IsStrategy(value string) bool {
+	for _, strategy := range Strategies {
+		if value == strategy {
+			return true
+		}
+	}
+	return false
+}
+
+// RoundRobin deals the files, sorted by path, to the shards in turn.
+func RoundRobin(files []File, count int) ([]Shard, error) {
+	shards, err := newShards(files, count)
+	if err != nil {
+		return nil, err
+	}
+	for i, file := range sortedByPath(files) {
+		shards[i%len(shards)].add(file)
+	}
+	return shards, nil
+}
+
+// ByFile keeps each file whole and balances the number of tests per shard,
+// assigning the largest files first to the shard with the fewest tests.
+func ByFile(files []File, count int) ([]Shard, error) {
+	shards, err := newShards(files, count)
+	if err != nil {
+		return nil, err
+	}
+
+	sorted := sortedByPath(files)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Tests > sorted[j].Tests })
+	for _, file := range sorted {
+		lightest := 0
+		for i := range shards {
+			if shards[i].Tests < shards[lightest].Tests ||
+				(shards[i].Tests == shards[lightest].Tests && len(shards[i].Files) < len(shards[lightest].Files)) {
+				lightest = i
+			}
+		}
+		shards[lightest].add(file)
+	}
+
+	for i := range shards {
+		sort.Strings(shards[i].Files)
+	}
+	return shards, nil
+}
+
+// ByTiming keeps each file whole and minimises the longest shard using the durations of previous runs,
+// in seconds by file path. Files with a duration are assigned longest first to the shard with the
+// shortest total. Files without one fall back to file count: each goes to the shard with the fewest
+// files, and PredictedSeconds only covers the files with a known duration.
+func ByTiming(files []File, durations map[string]float64, count int) ([]Shard, error) {
+	shards, err := newShards(files, count)
+	if err != nil {
+		return nil, err
+	}
+
+	var known, unknown []File
+	for _, file := range sortedByPath(files) {
+		if _, ok := durations[file.Path]; ok {
+			known = append(known, file)
+		} else {
+			unknown = append(unknown, file)
+		}
+	}
+
+	sort.SliceStable(known, func(i, j int) bool { return durations[known[i].Path] > durations[known[j].Path] })
+	for _, file := range known {
+		shortest := 0
+		for i := range shards {
+			if shards[i].PredictedSeconds < shards[shortest].PredictedSeconds {
+				shortest = i
+			}
+		}
+		shards[shortest].add(file)
+		shards[shortest].PredictedSeconds += durations[file.Path]
+	}
+
+	for _, file := range unknown {
+		fewest := 0
+		for i := range shards {
+			if len(shards[i].Files) < len(shards[fewest].Files) ||
+				(len(shards[i].Files) == len(shards[fewest].Files) && shards[i].PredictedSeconds < shards[fewest].PredictedSeconds) {
+				fewest = i
+			}
+		}
+		shards[fewest].add(file)
+	}
+
+	for i := range shards {
+		sort.Strings(shards[i].Files)
+	}
+	return shards, nil
+}
+
+// newShards returns count empty shards, or fewer when there are fewer files, so no shard is empty.
+func newShards(files []File, count int) ([]Shard, error) {
+	if count < 1 {
+		return nil, fmt.Errorf("shard count must be at least 1, got %d", count)
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no test files to shard")
+	}
+	count = min(count, len(files))
+
+	shards := make([]Shard, count)
+	for i := range shards {
+		shards[i].Index = i
+	}
+	return shards, nil
+}
+
+func (s *Shard) add(file File) {
+	s.Files = append(s.Files, file.Path)
+	s.Tests += file.Tests
+}
+
+func sortedByPath(files []File) []File {
+	sorted := append([]File{}, files...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Path < sorted[j].Path })
+	return sorted
+}
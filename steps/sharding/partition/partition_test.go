@@ -0,0 +1,94 @@
+package partition
+
+import (
+	"reflect"
+	"testing"
+)
+
+var exampleFiles = []File{
+	{Path: "patrol_test/d_test.dart", Tests: 1},
+	{Path: "patrol_test/a_test.dart", Tests: 6},
+	{Path: "patrol_test/c_test.dart", Tests: 2},
+	{Path: "patrol_test/b_test.dart", Tests: 3},
+}
+
+func TestRoundRobin(t *testing.T) {
+	shards, err := RoundRobin(exampleFiles, 2)
+	if err != nil {
+		t.Fatalf("RoundRobin() error: %v", err)
+	}
+
+	want := []Shard{
+		{Index: 0, Files: []string{"patrol_test/a_test.dart", "patrol_test/c_test.dart"}, Tests: 8},
+		{Index: 1, Files: []string{"patrol_test/b_test.dart", "patrol_test/d_test.dart"}, Tests: 4},
+	}
+	if !reflect.DeepEqual(shards, want) {
+		t.Errorf("RoundRobin() = %+v, want %+v", shards, want)
+	}
+}
+
+func TestByFile(t *testing.T) {
+	shards, err := ByFile(exampleFiles, 2)
+	if err != nil {
+		t.Fatalf("ByFile() error: %v", err)
+	}
+
+	want := []Shard{
+		{Index: 0, Files: []string{"patrol_test/a_test.dart"}, Tests: 6},
+		{Index: 1, Files: []string{"patrol_test/b_test.dart", "patrol_test/c_test.dart", "patrol_test/d_test.dart"}, Tests: 6},
+	}
+	if !reflect.DeepEqual(shards, want) {
+		t.Errorf("ByFile() = %+v, want %+v", shards, want)
+	}
+}
+
+func TestByTiming(t *testing.T) {
+	durations := map[string]float64{
+		"patrol_test/a_test.dart": 60,
+		"patrol_test/b_test.dart": 40,
+		"patrol_test/c_test.dart": 20,
+	}
+
+	shards, err := ByTiming(exampleFiles, durations, 2)
+	if err != nil {
+		t.Fatalf("ByTiming() error: %v", err)
+	}
+
+	// d_test.dart is unknown and goes to the shard with the fewest files
+	want := []Shard{
+		{Index: 0, Files: []string{"patrol_test/a_test.dart", "patrol_test/d_test.dart"}, Tests: 7, PredictedSeconds: 60},
+		{Index: 1, Files: []string{"patrol_test/b_test.dart", "patrol_test/c_test.dart"}, Tests: 5, PredictedSeconds: 60},
+	}
+	if !reflect.DeepEqual(shards, want) {
+		t.Errorf("ByTiming() = %+v, want %+v", shards, want)
+	}
+}
+
+func TestByTiming_WithoutDurationsBalancesFileCount(t *testing.T) {
+	shards, err := ByTiming(exampleFiles, nil, 2)
+	if err != nil {
+		t.Fatalf("ByTiming() error: %v", err)
+	}
+	if len(shards[0].Files) != 2 || len(shards[1].Files) != 2 {
+		t.Errorf("expected 2 files per shard, got %+v", shards)
+	}
+}
+
+func TestShardCountLimitedByFiles(t *testing.T) {
+	shards, err := RoundRobin(exampleFiles[:2], 5)
+	if err != nil {
+		t.Fatalf("RoundRobin() error: %v", err)
+	}
+	if len(shards) != 2 {
+		t.Errorf("expected 2 shards, got %d", len(shards))
+	}
+}
+
+func TestInvalidInput(t *testing.T) {
+	if _, err := ByFile(exampleFiles, 0); err == nil {
+		t.Error("expected error for a zero shard count")
+	}
+	if _, err := RoundRobin(nil, 2); err == nil {
+		t.Error("expected error without files")
+	}
+}
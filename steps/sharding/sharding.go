@@ -0,0 +1,268 @@
+package sharding
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	build_report "patrol_install/steps/build/build_report"
+	build_constants "patrol_install/steps/build/constants"
+	"patrol_install/steps/export_artifacts"
+	export_android_artifacts "patrol_install/steps/export_artifacts/export_android_artifacts"
+	export_ios_artifacts "patrol_install/steps/export_artifacts/export_ios_artifacts"
+	export_artifacts_utils "patrol_install/steps/export_artifacts/utils"
+	junit_timings "patrol_install/steps/sharding/junit_timings"
+	partition "patrol_install/steps/sharding/partition"
+	"patrol_install/steps/test_inventory"
+	"patrol_install/utils/print"
+)
+
+type Sharder interface {
+	// BuildShard builds the test files of the shard and returns the platforms whose build failed.
+	BuildShard(shard partition.Shard) ([]string, error)
+	// ExportShard exports the artifacts of the shard into its shard_<index> folders,
+	// skipping the failedPlatforms returned by its build.
+	ExportShard(shard partition.Shard, failedPlatforms []string) error
+}
+
+// Config is the sharding requested with SHARD_COUNT and SHARD_STRATEGY.
+type Config struct {
+	Count    int
+	Strategy string
+	// TimingsDir holds JUnit XML reports of previous runs, used by the by-timing strategy.
+	TimingsDir string
+}
+
+// IsEnabled reports whether the tests are split into more than one bundle.
+func (c *Config) IsEnabled() bool {
+	return c.Count > 1
+}
+
+type RunParams struct {
+	Runner    Sharder
+	Config    *Config
+	Inventory *test_inventory.Inventory
+	// InventoryError is why the inventory is missing, when the tests could not be discovered.
+	InventoryError error
+	// Target, Tags and ExcludedTags select the tests to shard, as for a single build.
+	Target       string
+	Tags         []string
+	ExcludedTags []string
+	Platform     string
+	// ProjectDir is where the manifest is written.
+	ProjectDir string
+	// ContinueOnPlatformFailure builds and exports every shard even when a platform fails,
+	// the failures are returned once the manifest is written.
+	ContinueOnPlatformFailure bool
+}
+
+// ConfigFromEnv reads SHARD_COUNT and SHARD_STRATEGY, defaulting to a single round-robin shard.
+func ConfigFromEnv() (*Config, error) {
+	config := &Config{Count: 1, Strategy: partition.StrategyRoundRobin}
+
+	if raw := strings.TrimSpace(os.Getenv(build_constants.ShardCount)); raw != "" {
+		count, err := strconv.Atoi(raw)
+		if err != nil || count < 1 {
+			return nil, fmt.Errorf("invalid %s %q: expected a positive number", build_constants.ShardCount, raw)
+		}
+		config.Count = count
+	}
+
+	if raw := strings.TrimSpace(os.Getenv(build_constants.ShardStrategy)); raw != "" {
+		if !partition.IsStrategy(raw) {
+			return nil, fmt.Errorf("invalid %s %q: expected one of %s",
+				build_constants.ShardStrategy, raw, strings.Join(partition.Strategies, ", "))
+		}
+		config.Strategy = raw
+	}
+
+	config.TimingsDir = strings.TrimSpace(os.Getenv(build_constants.ShardTimingsDir))
+	return config, nil
+}
+
+// Run splits the selected test files into shards, then builds and exports each shard in turn,
+// since every `patrol build` overwrites the outputs of the previous one. The shard manifest
+// is written and exported once all shards are built.
+func Run(params RunParams) (*Manifest, error) {
+	print.StepInitiated(fmt.Sprintf("--- Sharding tests (%d shards, %s) ---", params.Config.Count, params.Config.Strategy))
+
+	shards, err := Plan(params)
+	if err != nil {
+		print.Error("❌ " + err.Error())
+		return nil, err
+	}
+	for _, shard := range shards {
+		message := fmt.Sprintf("Shard %d: %d tests in %d files", shard.Index, shard.Tests, len(shard.Files))
+		if shard.PredictedSeconds > 0 {
+			message += fmt.Sprintf(", predicted %s", time.Duration(shard.PredictedSeconds*float64(time.Second)).Round(time.Second))
+		}
+		print.Vanilla(message)
+	}
+
+	manifest := &Manifest{
+		Strategy:   params.Config.Strategy,
+		ShardCount: len(shards),
+		Platform:   params.Platform,
+	}
+	var failures []error
+	failedShards := 0
+	for _, shard := range shards {
+		failuresBefore := len(failures)
+		print.StepInitiated(fmt.Sprintf("--- Building shard %d/%d ---", shard.Index+1, len(shards)))
+
+		failedBuilds, buildErr := params.Runner.BuildShard(shard)
+		if buildErr != nil {
+			if !params.ContinueOnPlatformFailure {
+				return nil, fmt.Errorf("shard %d: %w", shard.Index, buildErr)
+			}
+			failures = append(failures, fmt.Errorf("shard %d: %w", shard.Index, buildErr))
+		}
+		// The build outputs are shared by all shards, after a failure that names no platform
+		// every output may still be the one of the previous shard
+		var failedExports []string
+		if buildErr != nil && len(failedBuilds) == 0 {
+			print.Warning(fmt.Sprintf("⚠️ Skipping the export of shard %d, its build failed", shard.Index))
+		} else if err := params.Runner.ExportShard(shard, failedBuilds); err != nil {
+			if !params.ContinueOnPlatformFailure {
+				return nil, fmt.Errorf("shard %d: %w", shard.Index, err)
+			}
+			failures = append(failures, fmt.Errorf("shard %d: %w", shard.Index, err))
+			failedExports = export_artifacts.FailedExports(err)
+		}
+		if len(failures) > failuresBefore {
+			failedShards++
+		}
+		statuses := shardStatuses(params.Platform, buildErr, failedBuilds, failedExports)
+		manifest.Shards = append(manifest.Shards, manifestShard(shard, statuses))
+	}
+
+	manifestPath := filepath.Join(params.ProjectDir, ManifestPath)
+	if err := manifest.Write(manifestPath); err != nil {
+		print.Error("❌ " + err.Error())
+		return nil, err
+	}
+	if err := export_artifacts_utils.ExportEnv(ManifestPathEnvKey, manifestPath); err != nil {
+		print.Error(fmt.Sprintf("Error exporting env by Envman %s: %v", ManifestPathEnvKey, err))
+		return nil, err
+	}
+
+	if len(failures) > 0 {
+		print.Warning(fmt.Sprintf("⚠️ %d of %d shards failed, manifest exported to %s: %s", failedShards, len(shards), ManifestPathEnvKey, manifestPath))
+		return manifest, errors.Join(failures...)
+	}
+	print.StepCompleted(fmt.Sprintf("✅ %d shards built, manifest exported to %s: %s\n", len(shards), ManifestPathEnvKey, manifestPath))
+	return manifest, nil
+}
+
+// Plan selects the test files of the build and splits them with the configured strategy.
+func Plan(params RunParams) ([]partition.Shard, error) {
+	if params.Inventory == nil {
+		if params.InventoryError != nil {
+			return nil, fmt.Errorf("%s requires the test inventory: %w", build_constants.ShardCount, params.InventoryError)
+		}
+		return nil, fmt.Errorf("tests could not be discovered, %s requires the test inventory", build_constants.ShardCount)
+	}
+
+	files := selectedFiles(params.Inventory.Select(params.Target, params.Tags, params.ExcludedTags))
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no test in %s matches the tag filters, nothing to shard", params.Target)
+	}
+	if len(files) < params.Config.Count {
+		print.Warning(fmt.Sprintf("⚠️ Only %d test files are selected, building %d shards instead of %d",
+			len(files), len(files), params.Config.Count))
+	}
+
+	switch params.Config.Strategy {
+	case partition.StrategyByFile:
+		return partition.ByFile(files, params.Config.Count)
+	case partition.StrategyByTiming:
+		return partition.ByTiming(files, fileDurations(params, files), params.Config.Count)
+	default:
+		return partition.RoundRobin(files, params.Config.Count)
+	}
+}
+
+// fileDurations reads the durations of previous runs from the configured JUnit reports.
+// Missing or unreadable reports are reported and leave every file unknown.
+func fileDurations(params RunParams, files []partition.File) map[string]float64 {
+	if params.Config.TimingsDir == "" {
+		print.Warning(fmt.Sprintf("⚠️ %s is not set, %s shards are balanced by file count",
+			build_constants.ShardTimingsDir, partition.StrategyByTiming))
+		return nil
+	}
+
+	timings, err := junit_timings.ReadDir(params.Config.TimingsDir)
+	if err != nil {
+		print.Warning("⚠️ " + err.Error() + ", shards are balanced by file count")
+		return nil
+	}
+
+	paths := make([]string, 0, len(files))
+	for _, file := range files {
+		paths = append(paths, file.Path)
+	}
+	durations := junit_timings.FileDurations(timings, paths, params.Inventory.TestDirectory)
+	print.Vanilla(fmt.Sprintf("Found previous durations for %d of %d test files in %d JUnit test cases",
+		len(durations), len(files), len(timings)))
+	return durations
+}
+
+// selectedFiles groups the selected tests by file.
+func selectedFiles(tests []test_inventory.TestCase) []partition.File {
+	counts := map[string]int{}
+	for _, test := range tests {
+		counts[test.File]++
+	}
+
+	files := make([]partition.File, 0, len(counts))
+	for path, count := range counts {
+		files = append(files, partition.File{Path: path, Tests: count})
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].Path < files[j].Path })
+	return files
+}
+
+func manifestShard(shard partition.Shard, statuses map[string]string) ManifestShard {
+	entry := ManifestShard{
+		Index:     shard.Index,
+		Target:    strings.Join(shard.Files, ","),
+		Files:     shard.Files,
+		Tests:     shard.Tests,
+		Platforms: statuses,
+
+		PredictedDurationSeconds: shard.PredictedSeconds,
+	}
+	if statuses[build_constants.PlatformAndroid] == build_report.PlatformSucceeded {
+		entry.AndroidArtifacts = export_artifacts.ShardArtifactsPath(export_android_artifacts.AndroidArtifactsPath, shard.Index)
+	}
+	if statuses[build_constants.PlatformIOS] == build_report.PlatformSucceeded {
+		entry.IOSArtifacts = export_artifacts.ShardArtifactsPath(export_ios_artifacts.IOSArtifactsPath, shard.Index)
+	}
+	return entry
+}
+
+// shardStatuses returns the status of each platform selected by PLATFORM for one shard.
+// A failed build that names no platform leaves every platform without fresh outputs.
+func shardStatuses(platform string, buildErr error, failedBuilds, failedExports []string) map[string]string {
+	statuses := map[string]string{}
+	for _, name := range []string{build_constants.PlatformAndroid, build_constants.PlatformIOS} {
+		if platform != name && platform != build_constants.PlatformBoth {
+			continue
+		}
+		switch {
+		case buildErr != nil && len(failedBuilds) == 0, slices.Contains(failedBuilds, name):
+			statuses[name] = build_report.PlatformBuildFailed
+		case slices.Contains(failedExports, name):
+			statuses[name] = build_report.PlatformExportFailed
+		default:
+			statuses[name] = build_report.PlatformSucceeded
+		}
+	}
+	return statuses
+}
@@ -0,0 +1,24 @@
+package sharding
+
+import (
+	build "patrol_install/steps/build"
+	"patrol_install/steps/export_artifacts"
+	partition "patrol_install/steps/sharding/partition"
+)
+
+type SharderRunner struct {
+	ContinueOnPlatformFailure bool
+}
+
+func (p *SharderRunner) BuildShard(shard partition.Shard) ([]string, error) {
+	err := build.Run(&build.ShardBuilderRunner{Files: shard.Files})
+	return build.FailedPlatforms(err), err
+}
+
+func (p *SharderRunner) ExportShard(shard partition.Shard, failedPlatforms []string) error {
+	return export_artifacts.Run(&export_artifacts.ShardExporterRunner{
+		Index:                     shard.Index,
+		ContinueOnPlatformFailure: p.ContinueOnPlatformFailure,
+		FailedPlatforms:           failedPlatforms,
+	})
+}
@@ -0,0 +1,370 @@
+package sharding
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"testing"
+
+	build_report "patrol_install/steps/build/build_report"
+	build_constants "patrol_install/steps/build/constants"
+	"patrol_install/steps/export_artifacts"
+	export_artifacts_utils "patrol_install/steps/export_artifacts/utils"
+	partition "patrol_install/steps/sharding/partition"
+	"patrol_install/steps/test_inventory"
+)
+
+type sharderStub struct {
+	calls    []string
+	buildErr error
+	// failedPlatforms, when set, makes only the builds of these shards fail, returning the platforms with buildErr.
+	failedPlatforms map[int][]string
+	// exported holds the failed platforms passed to the export of each shard.
+	exported map[int][]string
+	// exportErr, when set, is returned by the export of every shard.
+	exportErr error
+}
+
+func (s *sharderStub) BuildShard(shard partition.Shard) ([]string, error) {
+	s.calls = append(s.calls, "build "+shard.Files[0])
+	if failed, ok := s.failedPlatforms[shard.Index]; ok {
+		return failed, s.buildErr
+	}
+	if s.failedPlatforms != nil {
+		return nil, nil
+	}
+	return nil, s.buildErr
+}
+
+func (s *sharderStub) ExportShard(shard partition.Shard, failedPlatforms []string) error {
+	s.calls = append(s.calls, "export "+shard.Files[0])
+	if s.exported == nil {
+		s.exported = map[int][]string{}
+	}
+	s.exported[shard.Index] = failedPlatforms
+	return s.exportErr
+}
+
+type exporterSpy struct {
+	exported map[string]string
+}
+
+func (s *exporterSpy) Export(key, value string) error {
+	s.exported[key] = value
+	return nil
+}
+
+func exampleInventory() *test_inventory.Inventory {
+	return &test_inventory.Inventory{
+		TestDirectory: "patrol_test",
+		Tests: []test_inventory.TestCase{
+			{File: "patrol_test/a_test.dart", Name: "a1", Tags: []string{"smoke"}},
+			{File: "patrol_test/a_test.dart", Name: "a2", Tags: []string{}},
+			{File: "patrol_test/b_test.dart", Name: "b1", Tags: []string{"smoke"}},
+			{File: "patrol_test/c_test.dart", Name: "c1", Tags: []string{}},
+		},
+	}
+}
+
+func TestConfigFromEnv(t *testing.T) {
+	t.Run("defaults", func(t *testing.T) {
+		t.Setenv(build_constants.ShardCount, "")
+		t.Setenv(build_constants.ShardStrategy, "")
+
+		config, err := ConfigFromEnv()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if config.Count != 1 || config.Strategy != partition.StrategyRoundRobin || config.IsEnabled() {
+			t.Errorf("unexpected config %+v", config)
+		}
+	})
+
+	t.Run("valid", func(t *testing.T) {
+		t.Setenv(build_constants.ShardCount, "4")
+		t.Setenv(build_constants.ShardStrategy, "by-file")
+
+		config, err := ConfigFromEnv()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if config.Count != 4 || config.Strategy != partition.StrategyByFile || !config.IsEnabled() {
+			t.Errorf("unexpected config %+v", config)
+		}
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		for count, strategy := range map[string]string{"0": "", "two": "", "2": "random"} {
+			t.Setenv(build_constants.ShardCount, count)
+			t.Setenv(build_constants.ShardStrategy, strategy)
+			if _, err := ConfigFromEnv(); err == nil {
+				t.Errorf("expected error for count=%q strategy=%q", count, strategy)
+			}
+		}
+	})
+}
+
+func TestPlan_RespectsTagFilters(t *testing.T) {
+	shards, err := Plan(RunParams{
+		Config:    &Config{Count: 3, Strategy: partition.StrategyRoundRobin},
+		Inventory: exampleInventory(),
+		Target:    "patrol_test",
+		Tags:      []string{"smoke"},
+	})
+	if err != nil {
+		t.Fatalf("Plan() error: %v", err)
+	}
+	if len(shards) != 2 {
+		t.Fatalf("expected 2 shards for 2 selected files, got %+v", shards)
+	}
+}
+
+func TestPlan_WithoutInventory(t *testing.T) {
+	if _, err := Plan(RunParams{Config: &Config{Count: 2}}); err == nil {
+		t.Fatal("expected error without an inventory")
+	}
+}
+
+func TestPlan_ReportsInventoryError(t *testing.T) {
+	scanError := errors.New("open patrol_test: no such file or directory")
+
+	_, err := Plan(RunParams{Config: &Config{Count: 2}, InventoryError: scanError})
+
+	if !errors.Is(err, scanError) {
+		t.Fatalf("expected the scan error as the cause, got %v", err)
+	}
+}
+
+func TestRun_BuildsExportsAndWritesManifest(t *testing.T) {
+	root := t.TempDir()
+	spy := &exporterSpy{exported: map[string]string{}}
+	export_artifacts_utils.SetEnvExporter(spy)
+	t.Cleanup(func() {
+		export_artifacts_utils.SetEnvExporter(nil)
+	})
+	stub := &sharderStub{}
+
+	manifest, err := Run(RunParams{
+		Runner:     stub,
+		Config:     &Config{Count: 2, Strategy: partition.StrategyRoundRobin},
+		Inventory:  exampleInventory(),
+		Target:     "patrol_test",
+		Platform:   build_constants.PlatformBoth,
+		ProjectDir: root,
+	})
+	if err != nil {
+		t.Fatalf("Run() error: %v", err)
+	}
+
+	wantCalls := []string{
+		"build patrol_test/a_test.dart", "export patrol_test/a_test.dart",
+		"build patrol_test/b_test.dart", "export patrol_test/b_test.dart",
+	}
+	if !reflect.DeepEqual(stub.calls, wantCalls) {
+		t.Errorf("calls = %v, want %v", stub.calls, wantCalls)
+	}
+
+	first := manifest.Shards[0]
+	if first.Target != "patrol_test/a_test.dart,patrol_test/c_test.dart" || first.Tests != 3 ||
+		first.AndroidArtifacts != filepath.Join("patrol", "android", "shard_0") ||
+		first.IOSArtifacts != filepath.Join("patrol", "ios", "shard_0") {
+		t.Errorf("unexpected first shard %+v", first)
+	}
+	wantPlatforms := map[string]string{
+		build_constants.PlatformAndroid: build_report.PlatformSucceeded,
+		build_constants.PlatformIOS:     build_report.PlatformSucceeded,
+	}
+	if !reflect.DeepEqual(first.Platforms, wantPlatforms) {
+		t.Errorf("platforms = %v, want %v", first.Platforms, wantPlatforms)
+	}
+
+	path := spy.exported[ManifestPathEnvKey]
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read manifest at %q: %v", path, err)
+	}
+	var written Manifest
+	if err := json.Unmarshal(data, &written); err != nil {
+		t.Fatalf("invalid manifest JSON: %v", err)
+	}
+	if written.ShardCount != 2 || len(written.Shards) != 2 || written.Strategy != partition.StrategyRoundRobin {
+		t.Errorf("unexpected written manifest %+v", written)
+	}
+}
+
+func TestRun_StopsOnBuildFailure(t *testing.T) {
+	stub := &sharderStub{buildErr: errors.New("gradle failed")}
+
+	_, err := Run(RunParams{
+		Runner:     stub,
+		Config:     &Config{Count: 2, Strategy: partition.StrategyRoundRobin},
+		Inventory:  exampleInventory(),
+		Target:     "patrol_test",
+		Platform:   build_constants.PlatformAndroid,
+		ProjectDir: t.TempDir(),
+	})
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	if len(stub.calls) != 1 {
+		t.Errorf("expected the run to stop after the first build, got %v", stub.calls)
+	}
+}
+
+func TestRun_ContinuesOnPlatformFailure(t *testing.T) {
+	spy := &exporterSpy{exported: map[string]string{}}
+	export_artifacts_utils.SetEnvExporter(spy)
+	t.Cleanup(func() {
+		export_artifacts_utils.SetEnvExporter(nil)
+	})
+	stub := &sharderStub{buildErr: errors.New("gradle failed")}
+
+	manifest, err := Run(RunParams{
+		Runner:                    stub,
+		Config:                    &Config{Count: 2, Strategy: partition.StrategyRoundRobin},
+		Inventory:                 exampleInventory(),
+		Target:                    "patrol_test",
+		Platform:                  build_constants.PlatformBoth,
+		ProjectDir:                t.TempDir(),
+		ContinueOnPlatformFailure: true,
+	})
+
+	// Every shard is still built and the manifest written, before failing. The failed builds
+	// name no platform, so none is exported rather than copying the outputs of a previous shard
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	if !slices.Equal(stub.calls, []string{"build patrol_test/a_test.dart", "build patrol_test/b_test.dart"}) {
+		t.Errorf("expected every shard to be built and none exported, got %v", stub.calls)
+	}
+	if manifest == nil || len(manifest.Shards) != 2 || spy.exported[ManifestPathEnvKey] == "" {
+		t.Fatalf("expected the manifest to be exported, got %+v", manifest)
+	}
+	for _, shard := range manifest.Shards {
+		if shard.AndroidArtifacts != "" || shard.IOSArtifacts != "" ||
+			shard.Platforms[build_constants.PlatformAndroid] != build_report.PlatformBuildFailed ||
+			shard.Platforms[build_constants.PlatformIOS] != build_report.PlatformBuildFailed {
+			t.Errorf("expected shard %d to record both builds as failed, got %+v", shard.Index, shard)
+		}
+	}
+}
+
+func TestRun_PassesEachShardItsOwnFailedPlatforms(t *testing.T) {
+	spy := &exporterSpy{exported: map[string]string{}}
+	export_artifacts_utils.SetEnvExporter(spy)
+	t.Cleanup(func() {
+		export_artifacts_utils.SetEnvExporter(nil)
+	})
+	// GIVEN the Android build of shard 0 fails and shard 1 builds
+	stub := &sharderStub{
+		buildErr:        errors.New("gradle failed"),
+		failedPlatforms: map[int][]string{0: {build_constants.PlatformAndroid}},
+	}
+
+	// WHEN running the sharded build
+	manifest, err := Run(RunParams{
+		Runner:                    stub,
+		Config:                    &Config{Count: 2, Strategy: partition.StrategyRoundRobin},
+		Inventory:                 exampleInventory(),
+		Target:                    "patrol_test",
+		Platform:                  build_constants.PlatformBoth,
+		ProjectDir:                t.TempDir(),
+		ContinueOnPlatformFailure: true,
+	})
+
+	// THEN only shard 0 skips Android, shard 1 is exported in full
+	if err == nil {
+		t.Fatal("expected the shard 0 failure")
+	}
+	if got := stub.exported[0]; len(got) != 1 || got[0] != build_constants.PlatformAndroid {
+		t.Errorf("expected shard 0 to skip android, got %v", got)
+	}
+	if got, ok := stub.exported[1]; !ok || len(got) != 0 {
+		t.Errorf("expected shard 1 to be exported without failed platforms, got %v (exported: %v)", got, ok)
+	}
+	// AND the manifest only points at the artifacts that were produced
+	first, second := manifest.Shards[0], manifest.Shards[1]
+	if first.Platforms[build_constants.PlatformAndroid] != build_report.PlatformBuildFailed || first.AndroidArtifacts != "" {
+		t.Errorf("expected shard 0 to record the failed Android build, got %+v", first)
+	}
+	if first.Platforms[build_constants.PlatformIOS] != build_report.PlatformSucceeded || first.IOSArtifacts == "" {
+		t.Errorf("expected shard 0 to keep its iOS artifacts, got %+v", first)
+	}
+	if second.AndroidArtifacts == "" || second.IOSArtifacts == "" {
+		t.Errorf("expected shard 1 to keep every artifact, got %+v", second)
+	}
+}
+
+func TestRun_RecordsFailedExportsInManifest(t *testing.T) {
+	spy := &exporterSpy{exported: map[string]string{}}
+	export_artifacts_utils.SetEnvExporter(spy)
+	t.Cleanup(func() {
+		export_artifacts_utils.SetEnvExporter(nil)
+	})
+	// GIVEN every shard builds but its iOS export fails
+	stub := &sharderStub{
+		exportErr: &export_artifacts.PlatformExportError{
+			Platform: build_constants.PlatformIOS,
+			Err:      errors.New("no .app found"),
+		},
+	}
+
+	// WHEN running the sharded build
+	manifest, err := Run(RunParams{
+		Runner:                    stub,
+		Config:                    &Config{Count: 2, Strategy: partition.StrategyRoundRobin},
+		Inventory:                 exampleInventory(),
+		Target:                    "patrol_test",
+		Platform:                  build_constants.PlatformBoth,
+		ProjectDir:                t.TempDir(),
+		ContinueOnPlatformFailure: true,
+	})
+
+	// THEN the manifest records the failed export and drops the iOS artifacts
+	if err == nil {
+		t.Fatal("expected the export failure")
+	}
+	for _, shard := range manifest.Shards {
+		if shard.Platforms[build_constants.PlatformIOS] != build_report.PlatformExportFailed || shard.IOSArtifacts != "" {
+			t.Errorf("expected shard %d to record the failed iOS export, got %+v", shard.Index, shard)
+		}
+		if shard.Platforms[build_constants.PlatformAndroid] != build_report.PlatformSucceeded || shard.AndroidArtifacts == "" {
+			t.Errorf("expected shard %d to keep its Android artifacts, got %+v", shard.Index, shard)
+		}
+	}
+}
+
+func TestPlan_ByTimingUsesPreviousResults(t *testing.T) {
+	dir := t.TempDir()
+	report := `<testsuite name="RunnerUITests">
+  <testcase classname="RunnerUITests" name="a_test a1" time="100"/>
+  <testcase classname="RunnerUITests" name="b_test b1" time="30"/>
+  <testcase classname="RunnerUITests" name="c_test c1" time="20"/>
+</testsuite>
+`
+	if err := os.WriteFile(filepath.Join(dir, "report.xml"), []byte(report), 0644); err != nil {
+		t.Fatalf("failed to write report: %v", err)
+	}
+
+	shards, err := Plan(RunParams{
+		Config:    &Config{Count: 2, Strategy: partition.StrategyByTiming, TimingsDir: dir},
+		Inventory: exampleInventory(),
+		Target:    "patrol_test",
+	})
+	if err != nil {
+		t.Fatalf("Plan() error: %v", err)
+	}
+
+	if !reflect.DeepEqual(shards[0].Files, []string{"patrol_test/a_test.dart"}) || shards[0].PredictedSeconds != 100 {
+		t.Errorf("unexpected first shard %+v", shards[0])
+	}
+	if shards[1].PredictedSeconds != 50 {
+		t.Errorf("unexpected second shard %+v", shards[1])
+	}
+	if entry := manifestShard(shards[1], map[string]string{}); entry.PredictedDurationSeconds != 50 {
+		t.Errorf("expected the prediction in the manifest, got %+v", entry)
+	}
+}
@@ -0,0 +1,283 @@
+package dart_scanner
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Declaration is a patrolTest or patrolWidgetTest found in a Dart file.
+type Declaration struct {
+	Name string
+	// Groups are the names of the enclosing group() calls, outermost first.
+	Groups []string
+	// Tags are the tags declared on the test and its groups, sorted.
+	Tags []string
+	Line int
+}
+
+// call is a test or group call and the span of its arguments in the source.
+type call struct {
+	function string
+	start    int
+	end      int
+	name     string
+	tags     []string
+}
+
+var callPattern = regexp.MustCompile(`\b(patrolTest|patrolWidgetTest|group)\s*\(`)
+
+var namedArgPattern = regexp.MustCompile(`^[A-Za-z_]\w*\s*:`)
+
+var stringLiteralPattern = regexp.MustCompile(`(?s)[rR]?('{3}.*?'{3}|"{3}.*?"{3}|'[^'\n]*'|"[^"\n]*")`)
+
+// Scan finds the Patrol test declarations in Dart source without running Dart.
+// Comments and string contents are ignored when looking for calls, so commented out
+// tests are skipped. Names that aren't string literals are reported as written in the source.
+func Scan(src []byte) []Declaration {
+	masked := mask(src)
+
+	var calls []call
+	for _, match := range callPattern.FindAllSubmatchIndex(masked, -1) {
+		open := match[1] - 1
+		end := closingParen(masked, open)
+		if end < 0 {
+			continue
+		}
+		args := splitArgs(masked, open+1, end)
+		c := call{function: string(masked[match[2]:match[3]]), start: open, end: end}
+		for i, arg := range args {
+			text := strings.TrimSpace(string(masked[arg[0]:arg[1]]))
+			if i == 0 && !isNamedArg(text) {
+				c.name = literalOrSource(src, masked, arg[0], arg[1])
+				continue
+			}
+			if strings.HasPrefix(text, "tags") && isNamedArg(text) {
+				c.tags = stringLiterals(src, masked, arg[0], arg[1])
+			}
+		}
+		calls = append(calls, c)
+	}
+
+	var declarations []Declaration
+	for _, test := range calls {
+		if test.function == "group" {
+			continue
+		}
+		declaration := Declaration{Name: test.name, Line: lineOf(src, test.start)}
+		tags := map[string]bool{}
+		for _, group := range calls {
+			if group.function == "group" && group.start < test.start && test.end < group.end {
+				declaration.Groups = append(declaration.Groups, group.name)
+				for _, tag := range group.tags {
+					tags[tag] = true
+				}
+			}
+		}
+		for _, tag := range test.tags {
+			tags[tag] = true
+		}
+		for tag := range tags {
+			declaration.Tags = append(declaration.Tags, tag)
+		}
+		sort.Strings(declaration.Tags)
+		declarations = append(declarations, declaration)
+	}
+	return declarations
+}
+
+func isNamedArg(text string) bool {
+	return namedArgPattern.MatchString(text)
+}
+
+// mask returns a copy of src with comments blanked and string contents replaced by '_',
+// keeping the quotes and the length so offsets match the original.
+func mask(src []byte) []byte {
+	out := make([]byte, len(src))
+	copy(out, src)
+	for i := 0; i < len(src); {
+		switch {
+		case hasPrefixAt(src, i, "//"):
+			for i < len(src) && src[i] != '\n' {
+				out[i] = ' '
+				i++
+			}
+		case hasPrefixAt(src, i, "/*"):
+			i = maskBlockComment(src, out, i)
+		case src[i] == '\'' || src[i] == '"':
+			raw := i > 0 && (src[i-1] == 'r' || src[i-1] == 'R') && (i < 2 || !isIdentByte(src[i-2]))
+			i = maskString(src, out, i, raw)
+		default:
+			i++
+		}
+	}
+	return out
+}
+
+// maskBlockComment blanks a possibly nested /* */ comment starting at i and returns the index after it.
+func maskBlockComment(src, out []byte, i int) int {
+	depth := 0
+	for i < len(src) {
+		switch {
+		case hasPrefixAt(src, i, "/*"):
+			depth++
+			out[i], out[i+1] = ' ', ' '
+			i += 2
+		case hasPrefixAt(src, i, "*/"):
+			depth--
+			out[i], out[i+1] = ' ', ' '
+			i += 2
+			if depth == 0 {
+				return i
+			}
+		default:
+			if src[i] != '\n' {
+				out[i] = ' '
+			}
+			i++
+		}
+	}
+	return i
+}
+
+// maskString masks the string literal starting at the quote at i and returns the index after it.
+func maskString(src, out []byte, i int, raw bool) int {
+	quote := string(src[i])
+	if hasPrefixAt(src, i, strings.Repeat(quote, 3)) {
+		quote = strings.Repeat(quote, 3)
+	}
+	i += len(quote)
+	for i < len(src) {
+		switch {
+		case hasPrefixAt(src, i, quote):
+			return i + len(quote)
+		case !raw && src[i] == '\\' && i+1 < len(src):
+			out[i], out[i+1] = '_', '_'
+			i += 2
+		case !raw && hasPrefixAt(src, i, "${"):
+			i = maskInterpolation(src, out, i)
+		case len(quote) == 1 && src[i] == '\n':
+			// Unterminated single line string
+			return i
+		default:
+			if src[i] != '\n' {
+				out[i] = '_'
+			}
+			i++
+		}
+	}
+	return i
+}
+
+// maskInterpolation masks ${...}, including strings nested inside it, and returns the index after it.
+func maskInterpolation(src, out []byte, i int) int {
+	depth := 0
+	for i < len(src) {
+		switch src[i] {
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				out[i] = '_'
+				return i + 1
+			}
+		case '\'', '"':
+			end := maskString(src, out, i, false)
+			for j := i; j < end; j++ {
+				out[j] = '_'
+			}
+			i = end
+			continue
+		}
+		out[i] = '_'
+		i++
+	}
+	return i
+}
+
+// closingParen returns the index of the parenthesis closing the one at open, or -1.
+func closingParen(masked []byte, open int) int {
+	depth := 0
+	for i := open; i < len(masked); i++ {
+		switch masked[i] {
+		case '(', '[', '{':
+			depth++
+		case ')', ']', '}':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// splitArgs returns the [start, end) spans of the top level arguments between start and end.
+func splitArgs(masked []byte, start, end int) [][2]int {
+	var args [][2]int
+	depth := 0
+	argStart := start
+	for i := start; i < end; i++ {
+		switch masked[i] {
+		case '(', '[', '{':
+			depth++
+		case ')', ']', '}':
+			depth--
+		case ',':
+			if depth == 0 {
+				args = append(args, [2]int{argStart, i})
+				argStart = i + 1
+			}
+		}
+	}
+	if strings.TrimSpace(string(masked[argStart:end])) != "" {
+		args = append(args, [2]int{argStart, end})
+	}
+	return args
+}
+
+// literalOrSource returns the concatenated contents of the string literals in the span,
+// or the trimmed source when the span isn't only made of string literals.
+func literalOrSource(src, masked []byte, start, end int) string {
+	source := strings.TrimSpace(string(src[start:end]))
+	rest := stringLiteralPattern.ReplaceAll(masked[start:end], nil)
+	if strings.TrimSpace(string(rest)) != "" {
+		return source
+	}
+	return strings.Join(stringLiterals(src, masked, start, end), "")
+}
+
+// stringLiterals returns the contents of the string literals in the span, in order.
+func stringLiterals(src, masked []byte, start, end int) []string {
+	var literals []string
+	for i := start; i < end; i++ {
+		if masked[i] != '\'' && masked[i] != '"' {
+			continue
+		}
+		quote := string(masked[i])
+		if hasPrefixAt(masked, i, strings.Repeat(quote, 3)) {
+			quote = strings.Repeat(quote, 3)
+		}
+		contentStart := i + len(quote)
+		closing := strings.Index(string(masked[contentStart:end]), quote)
+		if closing < 0 {
+			break
+		}
+		literals = append(literals, string(src[contentStart:contentStart+closing]))
+		i = contentStart + closing + len(quote) - 1
+	}
+	return literals
+}
+
+func lineOf(src []byte, offset int) int {
+	return strings.Count(string(src[:offset]), "\n") + 1
+}
+
+func hasPrefixAt(src []byte, i int, prefix string) bool {
+	return strings.HasPrefix(string(src[i:min(len(src), i+len(prefix))]), prefix)
+}
+
+func isIdentByte(b byte) bool {
+	return b == '_' || b == '$' || (b >= '0' && b <= '9') || (b >= 'a' && b <= 'z') || (b >= 'A' && b <= 'Z')
+}
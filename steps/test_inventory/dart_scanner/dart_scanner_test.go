@@ -0,0 +1,62 @@
+package dart_scanner
+
+import (
+	"reflect"
+	"testing"
+)
+
+const exampleTest = `import 'package:patrol/patrol.dart';
+
+void main() {
+  patrolTest('opens the app', ($) async {
+    await $.pumpWidgetAndSettle(const App());
+  });
+
+  group('login', tags: ['auth'], () {
+    patrolTest(
+      'with valid credentials',
+      tags: ['smoke', 'critical'],
+      ($) async {
+        await $('Log in').tap();
+      },
+    );
+
+    group("errors", () {
+      patrolWidgetTest('shows ${"invalid"} message', tags: 'regression', ($) async {});
+    });
+  });
+
+  // patrolTest('commented out', ($) async {});
+  /* patrolTest('also commented out', ($) async {}); */
+  final description = 'patrolTest("inside a string", ($) async {})';
+  patrolTest(description, ($) async {});
+  patrolTest('it\'s ' 'escaped', ($) async {});
+}
+`
+
+func TestScan(t *testing.T) {
+	got := Scan([]byte(exampleTest))
+
+	want := []Declaration{
+		{Name: "opens the app", Line: 4},
+		{Name: "with valid credentials", Groups: []string{"login"}, Tags: []string{"auth", "critical", "smoke"}, Line: 9},
+		{Name: `shows ${"invalid"} message`, Groups: []string{"login", "errors"}, Tags: []string{"auth", "regression"}, Line: 18},
+		{Name: "description", Line: 25},
+		{Name: `it\'s escaped`, Line: 26},
+	}
+
+	if len(got) != len(want) {
+		t.Fatalf("Scan() found %d declarations, want %d: %+v", len(got), len(want), got)
+	}
+	for i := range want {
+		if !reflect.DeepEqual(got[i], want[i]) {
+			t.Errorf("declaration %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestScan_NoDeclarations(t *testing.T) {
+	if got := Scan([]byte("void login() {}\n")); len(got) != 0 {
+		t.Errorf("Scan() = %+v, want none", got)
+	}
+}
@@ -0,0 +1,159 @@
+package test_inventory
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	dart_scanner "patrol_install/steps/test_inventory/dart_scanner"
+)
+
+// TestCase is a Patrol test declared in the test directory.
+type TestCase struct {
+	// File is the path of the Dart file, relative to the project and slash separated.
+	File   string   `json:"file"`
+	Name   string   `json:"name"`
+	Groups []string `json:"groups,omitempty"`
+	Tags   []string `json:"tags"`
+	Line   int      `json:"line"`
+}
+
+// Inventory lists the Patrol tests of a project.
+type Inventory struct {
+	TestDirectory string     `json:"testDirectory"`
+	Tests         []TestCase `json:"tests"`
+}
+
+// FullName returns the test name prefixed by its groups, as reported by the test runner.
+func (t TestCase) FullName() string {
+	return strings.Join(append(append([]string{}, t.Groups...), t.Name), " ")
+}
+
+// Scan parses every Dart file in testDirectory, relative to projectDir, and lists the declared tests.
+func Scan(projectDir, testDirectory string) (*Inventory, error) {
+	inventory := &Inventory{TestDirectory: filepath.ToSlash(testDirectory), Tests: []TestCase{}}
+
+	root := filepath.Join(projectDir, testDirectory)
+	err := filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() || filepath.Ext(path) != ".dart" {
+			return nil
+		}
+
+		src, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		relative, err := filepath.Rel(projectDir, path)
+		if err != nil {
+			return err
+		}
+
+		for _, declaration := range dart_scanner.Scan(src) {
+			tags := declaration.Tags
+			if tags == nil {
+				tags = []string{}
+			}
+			inventory.Tests = append(inventory.Tests, TestCase{
+				File:   filepath.ToSlash(relative),
+				Name:   declaration.Name,
+				Groups: declaration.Groups,
+				Tags:   tags,
+				Line:   declaration.Line,
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan %s: %w", root, err)
+	}
+	return inventory, nil
+}
+
+// DeclaredTags returns the sorted tags declared by at least one test.
+func (i *Inventory) DeclaredTags() []string {
+	seen := map[string]bool{}
+	for _, test := range i.Tests {
+		for _, tag := range test.Tags {
+			seen[tag] = true
+		}
+	}
+	tags := make([]string, 0, len(seen))
+	for tag := range seen {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+	return tags
+}
+
+// Files returns the sorted files declaring at least one test.
+func (i *Inventory) Files() []string {
+	seen := map[string]bool{}
+	var files []string
+	for _, test := range i.Tests {
+		if !seen[test.File] {
+			seen[test.File] = true
+			files = append(files, test.File)
+		}
+	}
+	sort.Strings(files)
+	return files
+}
+
+// Select returns the tests under target that the build includes. Like the tags passed to
+// `patrol build`, a test needs all of tags, and is excluded when it has all of excludedTags.
+func (i *Inventory) Select(target string, tags, excludedTags []string) []TestCase {
+	target = strings.TrimSuffix(filepath.ToSlash(filepath.Clean(target)), "/")
+
+	var selected []TestCase
+	for _, test := range i.Tests {
+		if target != "." && test.File != target && !strings.HasPrefix(test.File, target+"/") {
+			continue
+		}
+		if len(tags) > 0 && !hasAll(test.Tags, tags) {
+			continue
+		}
+		if len(excludedTags) > 0 && hasAll(test.Tags, excludedTags) {
+			continue
+		}
+		selected = append(selected, test)
+	}
+	return selected
+}
+
+// Write saves the inventory as indented JSON, creating the parent directory.
+func (i *Inventory) Write(path string) error {
+	data, err := json.MarshalIndent(i, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create folder %s: %w", filepath.Dir(path), err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
+
+func hasAll(tags, required []string) bool {
+	for _, tag := range required {
+		found := false
+		for _, candidate := range tags {
+			if candidate == tag {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,172 @@
+package test_inventory
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	export_artifacts_utils "patrol_install/steps/export_artifacts/utils"
+)
+
+const loginTests = `void main() {
+  group('login', tags: ['auth'], () {
+    patrolTest('succeeds', tags: ['smoke'], ($) async {});
+    patrolTest('fails', ($) async {});
+  });
+}
+`
+
+const cartTests = `void main() {
+  patrolTest('adds items', tags: ['smoke', 'slow'], ($) async {});
+}
+`
+
+type exporterSpy struct {
+	exported map[string]string
+}
+
+func (s *exporterSpy) Export(key, value string) error {
+	s.exported[key] = value
+	return nil
+}
+
+func writeProject(t *testing.T, files map[string]string) string {
+	t.Helper()
+	root := t.TempDir()
+	for path, content := range files {
+		full := filepath.Join(root, path)
+		if err := os.MkdirAll(filepath.Dir(full), 0755); err != nil {
+			t.Fatalf("failed to create dir: %v", err)
+		}
+		if err := os.WriteFile(full, []byte(content), 0644); err != nil {
+			t.Fatalf("failed to write %s: %v", path, err)
+		}
+	}
+	return root
+}
+
+func exampleProject(t *testing.T) string {
+	return writeProject(t, map[string]string{
+		"patrol_test/login_test.dart":     loginTests,
+		"patrol_test/cart/cart_test.dart": cartTests,
+		"patrol_test/helpers.dart":        "void login() {}\n",
+		"lib/main.dart":                   "void main() {}\n",
+	})
+}
+
+func TestScan(t *testing.T) {
+	inventory, err := Scan(exampleProject(t), "patrol_test")
+	if err != nil {
+		t.Fatalf("Scan() error: %v", err)
+	}
+
+	want := []TestCase{
+		{File: "patrol_test/cart/cart_test.dart", Name: "adds items", Tags: []string{"slow", "smoke"}, Line: 2},
+		{File: "patrol_test/login_test.dart", Name: "succeeds", Groups: []string{"login"}, Tags: []string{"auth", "smoke"}, Line: 3},
+		{File: "patrol_test/login_test.dart", Name: "fails", Groups: []string{"login"}, Tags: []string{"auth"}, Line: 4},
+	}
+	if !reflect.DeepEqual(inventory.Tests, want) {
+		t.Errorf("Tests = %+v, want %+v", inventory.Tests, want)
+	}
+	if got := inventory.DeclaredTags(); !reflect.DeepEqual(got, []string{"auth", "slow", "smoke"}) {
+		t.Errorf("DeclaredTags() = %v", got)
+	}
+	if got := inventory.Tests[1].FullName(); got != "login succeeds" {
+		t.Errorf("FullName() = %q, want %q", got, "login succeeds")
+	}
+}
+
+func TestSelect(t *testing.T) {
+	inventory, err := Scan(exampleProject(t), "patrol_test")
+	if err != nil {
+		t.Fatalf("Scan() error: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		target   string
+		tags     []string
+		excluded []string
+		want     int
+	}{
+		{name: "whole_directory", target: "patrol_test", want: 3},
+		{name: "single_file", target: "patrol_test/login_test.dart", want: 2},
+		{name: "subdirectory", target: "patrol_test/cart/", want: 1},
+		{name: "all_tags_required", target: "patrol_test", tags: []string{"smoke", "auth"}, want: 1},
+		{name: "excluded_tags", target: "patrol_test", excluded: []string{"slow"}, want: 2},
+		{name: "no_match", target: "patrol_test/cart", tags: []string{"auth"}, want: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := inventory.Select(tt.target, tt.tags, tt.excluded); len(got) != tt.want {
+				t.Errorf("Select() selected %d tests, want %d: %+v", len(got), tt.want, got)
+			}
+		})
+	}
+}
+
+func TestRun_WritesAndExportsInventory(t *testing.T) {
+	root := exampleProject(t)
+	spy := &exporterSpy{exported: map[string]string{}}
+	export_artifacts_utils.SetEnvExporter(spy)
+	t.Cleanup(func() {
+		export_artifacts_utils.SetEnvExporter(nil)
+	})
+
+	inventory, err := Run(RunParams{ProjectDir: root, Target: "patrol_test", Tags: "smoke, smok"})
+	if err != nil {
+		t.Fatalf("Run() error: %v", err)
+	}
+	if inventory == nil || len(inventory.Tests) != 3 {
+		t.Fatalf("unexpected inventory %+v", inventory)
+	}
+
+	path := spy.exported[InventoryPathEnvKey]
+	if path != filepath.Join(root, InventoryPath) {
+		t.Fatalf("exported %s = %q", InventoryPathEnvKey, path)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read inventory: %v", err)
+	}
+	var written Inventory
+	if err := json.Unmarshal(data, &written); err != nil {
+		t.Fatalf("invalid inventory JSON: %v", err)
+	}
+	if written.TestDirectory != "patrol_test" || len(written.Tests) != 3 {
+		t.Errorf("unexpected written inventory %+v", written)
+	}
+}
+
+func TestRun_ReturnsScanError(t *testing.T) {
+	// GIVEN a project without the test directory
+	root := t.TempDir()
+
+	// WHEN discovering the tests
+	inventory, err := Run(RunParams{ProjectDir: root, Target: "patrol_test"})
+
+	// THEN the scan failure is returned as a ScanError
+	var scanError *ScanError
+	if !errors.As(err, &scanError) {
+		t.Fatalf("expected a ScanError, got %v", err)
+	}
+	if inventory != nil {
+		t.Errorf("expected no inventory, got %+v", inventory)
+	}
+	if !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("expected the scan cause to be kept, got %v", err)
+	}
+}
+
+func TestParseTags(t *testing.T) {
+	if got := ParseTags(" smoke, ,auth "); !reflect.DeepEqual(got, []string{"smoke", "auth"}) {
+		t.Errorf("ParseTags() = %v", got)
+	}
+	if got := ParseTags(""); got != nil {
+		t.Errorf("ParseTags(\"\") = %v, want nil", got)
+	}
+}
@@ -0,0 +1,111 @@
+package test_inventory
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	verify_target "patrol_install/steps/build/steps/verify_target"
+	export_artifacts_utils "patrol_install/steps/export_artifacts/utils"
+	"patrol_install/utils/print"
+	"patrol_install/utils/suggest"
+)
+
+const (
+	InventoryPath       = "patrol/test_inventory.json"
+	InventoryPathEnvKey = "PATROL_TEST_INVENTORY_PATH"
+)
+
+type RunParams struct {
+	ProjectDir string
+	// Target is the TEST_TARGET_DIRECTORY being built.
+	Target string
+	// Tags and ExcludedTags are the raw, comma separated, TAGS and EXCLUDED_TAGS inputs.
+	Tags         string
+	ExcludedTags string
+}
+
+// ScanError is a failure to discover the tests. Discovery is best effort for a single build,
+// callers that need the inventory report it as the cause.
+type ScanError struct {
+	Err error
+}
+
+func (e *ScanError) Error() string {
+	return "could not discover Patrol tests: " + e.Err.Error()
+}
+
+func (e *ScanError) Unwrap() error {
+	return e.Err
+}
+
+// Run scans the Patrol tests of the project, exports the inventory and warns about tag filters
+// that can't match. Scanning is best effort: a failure is reported as a warning and returned
+// as a *ScanError, so the build can go on without the inventory.
+func Run(params RunParams) (*Inventory, error) {
+	print.StepInitiated("--- Discovering Patrol tests ---")
+
+	inventory, err := Scan(params.ProjectDir, verify_target.TestDirectory(params.ProjectDir))
+	if err != nil {
+		print.Warning("⚠️ Could not discover Patrol tests: " + err.Error())
+		return nil, &ScanError{Err: err}
+	}
+
+	inventoryPath := filepath.Join(params.ProjectDir, InventoryPath)
+	if err := inventory.Write(inventoryPath); err != nil {
+		print.Error("❌ " + err.Error())
+		return nil, err
+	}
+	if err := export_artifacts_utils.ExportEnv(InventoryPathEnvKey, inventoryPath); err != nil {
+		print.Error(fmt.Sprintf("Error exporting env by Envman %s: %v", InventoryPathEnvKey, err))
+		return nil, err
+	}
+
+	print.Vanilla(fmt.Sprintf("Found %d tests in %d files", len(inventory.Tests), len(inventory.Files())))
+
+	tags := ParseTags(params.Tags)
+	excludedTags := ParseTags(params.ExcludedTags)
+	warnUnknownTags(inventory, "TAGS", tags)
+	warnUnknownTags(inventory, "EXCLUDED_TAGS", excludedTags)
+
+	selected := inventory.Select(params.Target, tags, excludedTags)
+	if len(selected) == 0 {
+		print.Warning(fmt.Sprintf("⚠️ No test in %s matches TAGS %q and EXCLUDED_TAGS %q, the test bundle will be empty",
+			params.Target, params.Tags, params.ExcludedTags))
+	} else {
+		print.Vanilla(fmt.Sprintf("%d tests selected for the build", len(selected)))
+	}
+
+	print.StepCompleted(fmt.Sprintf("✅ Test inventory exported to %s: %s\n", InventoryPathEnvKey, inventoryPath))
+	return inventory, nil
+}
+
+// ParseTags splits a comma separated tag input, ignoring blank entries.
+func ParseTags(raw string) []string {
+	var tags []string
+	for _, tag := range strings.Split(raw, ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+func warnUnknownTags(inventory *Inventory, input string, tags []string) {
+	declared := inventory.DeclaredTags()
+	known := map[string]bool{}
+	for _, tag := range declared {
+		known[tag] = true
+	}
+
+	for _, tag := range tags {
+		if known[tag] {
+			continue
+		}
+		message := fmt.Sprintf("⚠️ %s references tag %q, which no test declares", input, tag)
+		if suggestions := suggest.Closest(tag, declared, 3); len(suggestions) > 0 {
+			message += ". Did you mean: " + strings.Join(suggestions, ", ") + "?"
+		}
+		print.Warning(message)
+	}
+}
@@ -0,0 +1,37 @@
+package validate
+
+import (
+	"fmt"
+	"strings"
+
+	config "patrol_install/steps/validate/validate_config"
+	"patrol_install/utils/print"
+)
+
+type ConfigCheckRunParams struct {
+	// ProjectDir is the root of the Flutter project.
+	ProjectDir string
+	// Platform is the PLATFORM being built, used to select the files to check.
+	Platform string
+}
+
+// RunConfigCheck statically checks the Patrol configuration of the project, so config mistakes
+// are reported before `patrol build` spends minutes in Gradle or Xcode.
+func RunConfigCheck(params ConfigCheckRunParams) error {
+	print.StepInitiated("--- Checking Patrol configuration ---")
+
+	problems := config.CheckProject(params.ProjectDir, params.Platform)
+	if len(problems) == 0 {
+		print.StepCompleted("✅ Patrol configuration is valid\n")
+		return nil
+	}
+
+	descriptions := make([]string, 0, len(problems))
+	for _, problem := range problems {
+		descriptions = append(descriptions, problem.String())
+	}
+
+	err := fmt.Errorf("invalid Patrol configuration:\n  %s", strings.Join(descriptions, "\n  "))
+	print.Error("❌ " + err.Error())
+	return err
+}
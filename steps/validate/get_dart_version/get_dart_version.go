@@ -0,0 +1,89 @@
+package get_dart_version
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	v "github.com/Masterminds/semver/v3"
+
+	"patrol_install/commands"
+	regex "patrol_install/constants"
+	flutter "patrol_install/steps/validate/get_flutter_version"
+	"patrol_install/utils/exec"
+)
+
+// Detection methods, in the order they are tried.
+const (
+	MethodDartVersion    = "dart --version"
+	MethodFlutterMachine = "flutter --version --machine"
+)
+
+var DartVersionCmd = commands.DartVersion
+
+type CommandExecutor func(cmd commands.Command) (string, error)
+
+// Detection is the detected Dart SDK version and the method that found it.
+type Detection struct {
+	Version *v.Version
+	Method  string
+}
+
+// dartSDKField matches the leading version of the machine JSON dartSdkVersion field,
+// which reads e.g. "3.5.0 (build 3.5.0-180.3.beta)" on the beta channel.
+var dartSDKField = regexp.MustCompile(`^v?(\d+\.\d+\.\d+(?:-[0-9A-Za-z.-]+)?)`)
+
+// DetectDartVersion runs `dart --version` and falls back to the Dart SDK reported by Flutter.
+// The executor parameter allows for dependency injection in tests. Pass nil to use the default executor.
+func DetectDartVersion(executor CommandExecutor) (*Detection, error) {
+	run := executor
+	if run == nil {
+		run = exec.CombinedCommand
+	}
+
+	output, err := run(DartVersionCmd)
+	if err == nil {
+		var version *v.Version
+		version, err = ParseDartVersion(output)
+		if err == nil {
+			return &Detection{Version: version, Method: MethodDartVersion}, nil
+		}
+	}
+	failures := []string{fmt.Sprintf("%s: %s", MethodDartVersion, err)}
+
+	output, err = run(flutter.FlutterVersionMachineCmd)
+	if err == nil {
+		var info *flutter.FlutterInfo
+		info, err = flutter.ParseMachineVersion(output)
+		if err == nil {
+			var version *v.Version
+			version, err = ParseDartSDKField(info.DartSDKVersion)
+			if err == nil {
+				return &Detection{Version: version, Method: MethodFlutterMachine}, nil
+			}
+		}
+	}
+	failures = append(failures, fmt.Sprintf("%s: %s", MethodFlutterMachine, err))
+
+	return nil, fmt.Errorf("could not detect Dart SDK version (%s)", strings.Join(failures, "; "))
+}
+
+// ParseDartVersion extracts the version from `dart --version` output, e.g.
+//
+//	Dart SDK version: 3.8.0 (stable) (Wed May 14 09:07:14 2025 -0700) on "macos_arm64"
+func ParseDartVersion(output string) (*v.Version, error) {
+	match := regex.Version("Dart SDK version").FindStringSubmatch(output)
+	if len(match) < 2 {
+		return nil, fmt.Errorf("could not find Dart SDK version in output")
+	}
+	return flutter.ParseVersion(match[1])
+}
+
+// ParseDartSDKField parses the dartSdkVersion field of the Flutter machine JSON.
+func ParseDartSDKField(field string) (*v.Version, error) {
+	match := dartSDKField.FindStringSubmatch(strings.TrimSpace(field))
+	if len(match) < 2 {
+		return nil, fmt.Errorf("could not find Dart SDK version in %q", field)
+	}
+	return flutter.ParseVersion(match[1])
+}
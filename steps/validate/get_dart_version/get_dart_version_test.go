@@ -0,0 +1,99 @@
+package get_dart_version
+
+import (
+	"errors"
+	"testing"
+
+	"patrol_install/commands"
+	commands_utils "patrol_install/commands/utils"
+)
+
+const dartVersionOutput = `Dart SDK version: 3.8.0 (stable) (Wed May 14 09:07:14 2025 -0700) on "macos_arm64"
+`
+
+const machineOutputBeta = `{
+  "frameworkVersion": "3.24.0-0.2.pre",
+  "channel": "beta",
+  "dartSdkVersion": "3.5.0 (build 3.5.0-180.3.beta)",
+  "flutterVersion": "3.24.0-0.2.pre"
+}`
+
+func Test_ParseDartVersion(t *testing.T) {
+	version, err := ParseDartVersion(dartVersionOutput)
+	if err != nil {
+		t.Fatalf("ParseDartVersion() error: %v", err)
+	}
+	if version.String() != "3.8.0" {
+		t.Errorf("ParseDartVersion() = %s, want 3.8.0", version)
+	}
+
+	version, err = ParseDartVersion(`Dart SDK version: 3.9.0-100.0.dev (dev) on "linux_x64"`)
+	if err != nil {
+		t.Fatalf("ParseDartVersion() error: %v", err)
+	}
+	if version.String() != "3.9.0-100.0.dev" {
+		t.Errorf("ParseDartVersion() = %s, want 3.9.0-100.0.dev", version)
+	}
+
+	if _, err := ParseDartVersion("dart: command not found"); err == nil {
+		t.Error("ParseDartVersion() should error without a version")
+	}
+}
+
+func Test_ParseDartSDKField(t *testing.T) {
+	tests := map[string]string{
+		"3.8.0":                          "3.8.0",
+		"3.5.0 (build 3.5.0-180.3.beta)": "3.5.0",
+	}
+	for field, want := range tests {
+		version, err := ParseDartSDKField(field)
+		if err != nil {
+			t.Fatalf("ParseDartSDKField(%q) error: %v", field, err)
+		}
+		if version.String() != want {
+			t.Errorf("ParseDartSDKField(%q) = %s, want %s", field, version, want)
+		}
+	}
+
+	if _, err := ParseDartSDKField(""); err == nil {
+		t.Error("ParseDartSDKField() should error on an empty field")
+	}
+}
+
+func Test_DetectDartVersion(t *testing.T) {
+	t.Run("dart_version", func(t *testing.T) {
+		detection, err := DetectDartVersion(func(cmd commands.Command) (string, error) {
+			return dartVersionOutput, nil
+		})
+		if err != nil {
+			t.Fatalf("DetectDartVersion() error: %v", err)
+		}
+		if detection.Version.String() != "3.8.0" || detection.Method != MethodDartVersion {
+			t.Errorf("unexpected detection %+v", detection)
+		}
+	})
+
+	t.Run("falls_back_to_flutter_machine", func(t *testing.T) {
+		detection, err := DetectDartVersion(func(cmd commands.Command) (string, error) {
+			if commands_utils.IsSameCommand(cmd, DartVersionCmd) {
+				return "", errors.New("dart: command not found")
+			}
+			return machineOutputBeta, nil
+		})
+		if err != nil {
+			t.Fatalf("DetectDartVersion() error: %v", err)
+		}
+		if detection.Version.String() != "3.5.0" || detection.Method != MethodFlutterMachine {
+			t.Errorf("unexpected detection %+v", detection)
+		}
+	})
+
+	t.Run("all_methods_fail", func(t *testing.T) {
+		_, err := DetectDartVersion(func(cmd commands.Command) (string, error) {
+			return "", errors.New("not found")
+		})
+		if err == nil {
+			t.Fatal("DetectDartVersion() should error when every method fails")
+		}
+	})
+}
@@ -0,0 +1,156 @@
+package get_flutter_version
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	v "github.com/Masterminds/semver/v3"
+
+	"patrol_install/commands"
+	"patrol_install/utils/exec"
+	"patrol_install/utils/print"
+)
+
+// Detection methods, from fastest to slowest.
+const (
+	MethodVersionJSON = "flutter.version.json"
+	MethodVersionFile = "version file"
+	MethodMachine     = "flutter --version --machine"
+	MethodBanner      = "flutter --version"
+
+	flutterRootEnvKey = "FLUTTER_ROOT"
+)
+
+var FlutterVersionMachineCmd = commands.FlutterVersionMachine
+
+type CommandExecutor func(cmd commands.Command) (string, error)
+
+// FlutterInfo describes the Flutter SDK used for the build.
+// Fields other than Version are empty when the detection method doesn't provide them.
+type FlutterInfo struct {
+	Version           *v.Version
+	Channel           string
+	FrameworkRevision string
+	EngineRevision    string
+	DartSDKVersion    string
+	// Root is the Flutter SDK directory, from FLUTTER_ROOT or reported by `flutter --version --machine`.
+	Root   string
+	Method string
+}
+
+// machineVersion is the JSON written by `flutter --version --machine` and bin/cache/flutter.version.json.
+type machineVersion struct {
+	FrameworkVersion  string `json:"frameworkVersion"`
+	FlutterVersion    string `json:"flutterVersion"`
+	Channel           string `json:"channel"`
+	FrameworkRevision string `json:"frameworkRevision"`
+	EngineRevision    string `json:"engineRevision"`
+	DartSDKVersion    string `json:"dartSdkVersion"`
+	FlutterRoot       string `json:"flutterRoot"`
+}
+
+// GetFlutterInfo detects the Flutter SDK without spawning Flutter when possible:
+// it reads the SDK version files first when FLUTTER_ROOT is set, then runs `flutter --version --machine`,
+// and finally falls back to parsing the human readable banner.
+// The SDK root isn't derived from the flutter executable on PATH, which is a shim with fvm or asdf,
+// Flutter reports its own root in the machine output instead.
+// The executor parameter allows for dependency injection in tests. Pass nil to use the default executor.
+func GetFlutterInfo(executor CommandExecutor) (*FlutterInfo, error) {
+	run := executor
+	if run == nil {
+		run = exec.Command
+	}
+
+	if root := strings.TrimSpace(os.Getenv(flutterRootEnvKey)); root != "" {
+		info, err := ReadSDKVersionFiles(root)
+		if err == nil {
+			info.Root = root
+			return info, nil
+		}
+		print.Warning(fmt.Sprintf("Could not read the Flutter version from %s: %s", root, err))
+	}
+
+	output, err := run(FlutterVersionMachineCmd)
+	if err == nil {
+		info, parseErr := ParseMachineVersion(output)
+		if parseErr == nil {
+			info.Method = MethodMachine
+			return info, nil
+		}
+		err = parseErr
+	}
+	print.Warning("Could not read `flutter --version --machine`: " + err.Error())
+
+	output, err = run(FlutterVersionCmd)
+	if err != nil {
+		return nil, err
+	}
+	cleaned, err := CleanVersion(output)
+	if err != nil {
+		return nil, err
+	}
+	version, err := ParseVersion(cleaned)
+	if err != nil {
+		return nil, err
+	}
+	return &FlutterInfo{Version: version, Method: MethodBanner}, nil
+}
+
+// ReadSDKVersionFiles reads bin/cache/flutter.version.json, or the legacy version file, from the SDK root.
+func ReadSDKVersionFiles(root string) (*FlutterInfo, error) {
+	data, err := os.ReadFile(filepath.Join(root, "bin", "cache", "flutter.version.json"))
+	if err == nil {
+		info, err := ParseMachineVersion(string(data))
+		if err != nil {
+			return nil, err
+		}
+		info.Method = MethodVersionJSON
+		return info, nil
+	}
+
+	data, err = os.ReadFile(filepath.Join(root, "version"))
+	if err != nil {
+		return nil, fmt.Errorf("no version file found in %s", root)
+	}
+	version, err := ParseVersion(cleanVersion(string(data)))
+	if err != nil {
+		return nil, err
+	}
+	return &FlutterInfo{Version: version, Method: MethodVersionFile}, nil
+}
+
+// ParseMachineVersion parses the JSON version description. Lines printed around the JSON,
+// e.g. download progress on a fresh SDK, are ignored.
+func ParseMachineVersion(output string) (*FlutterInfo, error) {
+	start := strings.Index(output, "{")
+	end := strings.LastIndex(output, "}")
+	if start < 0 || end < start {
+		return nil, fmt.Errorf("could not find version JSON in output")
+	}
+
+	var machine machineVersion
+	if err := json.Unmarshal([]byte(output[start:end+1]), &machine); err != nil {
+		return nil, fmt.Errorf("invalid version JSON: %w", err)
+	}
+
+	rawVersion := machine.FlutterVersion
+	if rawVersion == "" {
+		rawVersion = machine.FrameworkVersion
+	}
+	version, err := ParseVersion(cleanVersion(rawVersion))
+	if err != nil {
+		return nil, err
+	}
+
+	return &FlutterInfo{
+		Version:           version,
+		Channel:           machine.Channel,
+		FrameworkRevision: machine.FrameworkRevision,
+		EngineRevision:    machine.EngineRevision,
+		DartSDKVersion:    machine.DartSDKVersion,
+		Root:              machine.FlutterRoot,
+	}, nil
+}
@@ -0,0 +1,166 @@
+package get_flutter_version
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"patrol_install/commands"
+	commands_utils "patrol_install/commands/utils"
+)
+
+const machineOutput = `{
+  "frameworkVersion": "3.32.0",
+  "channel": "stable",
+  "repositoryUrl": "https://github.com/flutter/flutter.git",
+  "frameworkRevision": "be698c48a6",
+  "frameworkCommitDate": "2025-05-19 12:59:14 -0700",
+  "engineRevision": "1881800949",
+  "dartSdkVersion": "3.8.0",
+  "devToolsVersion": "2.45.1",
+  "flutterVersion": "3.32.0",
+  "flutterRoot": "/opt/flutter"
+}`
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatalf("failed to create dir: %v", err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write %s: %v", path, err)
+	}
+}
+
+func Test_ParseMachineVersion(t *testing.T) {
+	info, err := ParseMachineVersion("Downloading Material fonts...\n" + machineOutput + "\n")
+	if err != nil {
+		t.Fatalf("ParseMachineVersion() error: %v", err)
+	}
+	if info.Version.String() != "3.32.0" {
+		t.Errorf("Version = %s, want 3.32.0", info.Version)
+	}
+	if info.Channel != "stable" || info.FrameworkRevision != "be698c48a6" || info.EngineRevision != "1881800949" || info.DartSDKVersion != "3.8.0" {
+		t.Errorf("unexpected info %+v", info)
+	}
+
+	if _, err := ParseMachineVersion("Flutter 3.32.0 • channel stable"); err == nil {
+		t.Error("ParseMachineVersion() should error without JSON")
+	}
+	if _, err := ParseMachineVersion(`{"channel": "stable"}`); err == nil {
+		t.Error("ParseMachineVersion() should error without a version")
+	}
+}
+
+func Test_ReadSDKVersionFiles(t *testing.T) {
+	t.Run("version_json", func(t *testing.T) {
+		root := t.TempDir()
+		writeFile(t, filepath.Join(root, "bin", "cache", "flutter.version.json"), machineOutput)
+
+		info, err := ReadSDKVersionFiles(root)
+		if err != nil {
+			t.Fatalf("ReadSDKVersionFiles() error: %v", err)
+		}
+		if info.Method != MethodVersionJSON || info.Version.String() != "3.32.0" || info.Channel != "stable" {
+			t.Errorf("unexpected info %+v", info)
+		}
+	})
+
+	t.Run("legacy_version_file", func(t *testing.T) {
+		root := t.TempDir()
+		writeFile(t, filepath.Join(root, "version"), "3.16.9\n")
+
+		info, err := ReadSDKVersionFiles(root)
+		if err != nil {
+			t.Fatalf("ReadSDKVersionFiles() error: %v", err)
+		}
+		if info.Method != MethodVersionFile || info.Version.String() != "3.16.9" {
+			t.Errorf("unexpected info %+v", info)
+		}
+	})
+
+	t.Run("no_files", func(t *testing.T) {
+		if _, err := ReadSDKVersionFiles(t.TempDir()); err == nil {
+			t.Error("ReadSDKVersionFiles() should error without version files")
+		}
+	})
+}
+
+func Test_GetFlutterInfo_PrefersSDKFiles(t *testing.T) {
+	// GIVEN a Flutter root with a version file
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "bin", "cache", "flutter.version.json"), machineOutput)
+	t.Setenv("FLUTTER_ROOT", root)
+
+	// WHEN detecting Flutter
+	called := false
+	info, err := GetFlutterInfo(func(cmd commands.Command) (string, error) {
+		called = true
+		return "", nil
+	})
+
+	// THEN Flutter is not spawned
+	if err != nil {
+		t.Fatalf("GetFlutterInfo() error: %v", err)
+	}
+	if called {
+		t.Error("expected no command to run")
+	}
+	if info.Method != MethodVersionJSON {
+		t.Errorf("Method = %q, want %q", info.Method, MethodVersionJSON)
+	}
+	if info.Root != root {
+		t.Errorf("Root = %q, want %q", info.Root, root)
+	}
+}
+
+func Test_GetFlutterInfo_WithoutFlutterRootAsksFlutter(t *testing.T) {
+	// GIVEN no FLUTTER_ROOT, e.g. flutter on PATH is an fvm or asdf shim
+	t.Setenv("FLUTTER_ROOT", "")
+
+	// WHEN detecting Flutter
+	info, err := GetFlutterInfo(func(cmd commands.Command) (string, error) {
+		return machineOutput, nil
+	})
+
+	// THEN the root reported by Flutter is used
+	if err != nil {
+		t.Fatalf("GetFlutterInfo() error: %v", err)
+	}
+	if info.Method != MethodMachine || info.Root != "/opt/flutter" {
+		t.Errorf("unexpected info %+v", info)
+	}
+}
+
+func Test_GetFlutterInfo_FallsBackToCommands(t *testing.T) {
+	// GIVEN a Flutter root without version files
+	t.Setenv("FLUTTER_ROOT", t.TempDir())
+
+	t.Run("machine_json", func(t *testing.T) {
+		info, err := GetFlutterInfo(func(cmd commands.Command) (string, error) {
+			return machineOutput, nil
+		})
+		if err != nil {
+			t.Fatalf("GetFlutterInfo() error: %v", err)
+		}
+		if info.Method != MethodMachine || info.DartSDKVersion != "3.8.0" || info.Root != "/opt/flutter" {
+			t.Errorf("unexpected info %+v", info)
+		}
+	})
+
+	t.Run("banner", func(t *testing.T) {
+		info, err := GetFlutterInfo(func(cmd commands.Command) (string, error) {
+			if commands_utils.IsSameCommand(cmd, FlutterVersionMachineCmd) {
+				return "", errors.New("Could not find an option named \"machine\".")
+			}
+			return "Flutter 3.35.7 • channel stable • https://github.com/flutter/flutter.git", nil
+		})
+		if err != nil {
+			t.Fatalf("GetFlutterInfo() error: %v", err)
+		}
+		if info.Method != MethodBanner || info.Version.String() != "3.35.7" {
+			t.Errorf("unexpected info %+v", info)
+		}
+	})
+}
@@ -8,7 +8,6 @@ import (
 
 	"patrol_install/commands"
 	regex "patrol_install/constants"
-	"patrol_install/utils/exec"
 )
 
 var FlutterVersionCmd = commands.FlutterVersion
@@ -32,24 +31,6 @@ func ParseVersion(versionStr string) (*v.Version, error) {
 	return parsedVersion, nil
 }
 
-func GetFlutterVersion(cmd commands.Command) (*v.Version, error) {
-	output, err := exec.Command(cmd)
-	if err != nil {
-		return nil, err
-	}
-
-	cleaned, err := CleanVersion(output)
-	if err != nil {
-		return nil, err
-	}
-
-	parsedVersion, err := ParseVersion(cleaned)
-	if err != nil {
-		return nil, err
-	}
-	return parsedVersion, nil
-}
-
 func cleanVersion(version string) string {
 	version = strings.TrimSpace(version)
 	version = strings.TrimPrefix(version, "v")
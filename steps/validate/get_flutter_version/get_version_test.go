@@ -29,6 +29,15 @@ func Test_CleanVersion(t *testing.T) {
 		t.Errorf("CleanVersion() = %q, want %q", got, "3.35.7")
 	}
 
+	outputBeta := "Flutter 3.33.0-0.1.pre • channel beta • https://github.com/flutter/flutter.git"
+	got, err = CleanVersion(outputBeta)
+	if err != nil {
+		t.Fatalf("CleanVersion() error: %v", err)
+	}
+	if got != "3.33.0-0.1.pre" {
+		t.Errorf("CleanVersion() = %q, want %q", got, "3.33.0-0.1.pre")
+	}
+
 	outputInvalid := "Flutter stable"
 	_, err = CleanVersion(outputInvalid)
 	if err == nil {
@@ -14,6 +14,8 @@ import (
 
 var FlutterPubDepsCmd = commands.FlutterPubDependencies
 
+const dependencyOverridesSection = "dependency overrides"
+
 func GetPatrolVersion(cmd commands.Command) (*v.Version, error) {
 
 	if !commands_utils.IsSameCommand(cmd, FlutterPubDepsCmd) {
@@ -34,21 +36,41 @@ func GetPatrolVersion(cmd commands.Command) (*v.Version, error) {
 	return version, nil
 }
 
+// GetPatrolVersionFromLog reads the patrol version from `flutter pub deps --style=compact` output.
+// An entry in the "dependency overrides" section wins over the regular dependency entry.
 func GetPatrolVersionFromLog(log string) (*v.Version, error) {
 	scanner := bufio.NewScanner(strings.NewReader(log))
+	section := ""
+	var rawVersion string
+	found := false
+
 	for scanner.Scan() {
-		line := scanner.Text()
-		if strings.HasPrefix(strings.TrimSpace(line), "- patrol ") {
-			fields := strings.Fields(line)
-			if len(fields) >= 3 {
-				rawVersion := fields[2]
-				version, err := v.NewVersion(rawVersion)
-				if err != nil {
-					return nil, fmt.Errorf("invalid version format: %v", err)
-				}
-				return version, nil
-			}
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasSuffix(line, ":") && !strings.HasPrefix(line, "-") {
+			section = strings.ToLower(strings.TrimSuffix(line, ":"))
+			continue
+		}
+		if !strings.HasPrefix(line, "- patrol ") {
+			continue
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) < 3 {
+			continue
+		}
+		if !found || section == dependencyOverridesSection {
+			rawVersion = fields[2]
+			found = true
 		}
 	}
-	return nil, fmt.Errorf("patrol package not found")
+
+	if !found {
+		return nil, fmt.Errorf("patrol package not found")
+	}
+
+	version, err := v.NewVersion(rawVersion)
+	if err != nil {
+		return nil, fmt.Errorf("invalid version format: %v", err)
+	}
+	return version, nil
 }
@@ -53,6 +53,18 @@ func Test_GetPatrolVersionFromLog(t *testing.T) {
 			want:    "3.15.1+1",
 			wantErr: true,
 		},
+		{
+			name: "Dependency override wins over dev dependency",
+			log: `
+dev dependencies:
+- patrol 3.15.1 [boolean_selector equatable flutter flutter_test http json_annotation meta patrol_finders patrol_log shelf test_api]
+
+dependency overrides:
+- patrol 3.16.0 [boolean_selector equatable flutter flutter_test http json_annotation meta patrol_finders patrol_log shelf test_api]
+`,
+			want:    "3.16.0",
+			wantErr: false,
+		},
 		{
 			name:    "Valid Semantic version format",
 			log:     "- patrol v3.15.1+1 [boolean_selector equatable flutter flutter_test http json_annotation meta patrol_finders patrol_log shelf test_api]",
@@ -0,0 +1,129 @@
+package get_patrol_version
+
+import (
+	"fmt"
+	"path/filepath"
+
+	v "github.com/Masterminds/semver/v3"
+
+	"patrol_install/commands"
+	"patrol_install/utils/print"
+	"patrol_install/utils/pubspec"
+)
+
+const (
+	patrolPackage = "patrol"
+
+	// SourcePubDeps marks a version read from `flutter pub deps`, which does not report the source.
+	SourcePubDeps = "pub deps"
+
+	overriddenDependency = "direct overridden"
+)
+
+// PatrolDependency is the resolved patrol package of the project.
+type PatrolDependency struct {
+	Version *v.Version
+	// Source is the pub source from pubspec.lock: hosted, git or path.
+	Source string
+	// Location is the git URL and ref or the local path for non-hosted sources.
+	Location string
+	// Overridden is true when patrol is pinned through dependency_overrides.
+	Overridden bool
+}
+
+// IsHosted reports whether patrol comes from a pub server, i.e. a published release.
+func (d *PatrolDependency) IsHosted() bool {
+	return d.Source == pubspec.SourceHosted || d.Source == SourcePubDeps
+}
+
+// Description returns the source details shown in the validation output.
+func (d *PatrolDependency) Description() string {
+	description := d.Source
+	if d.Location != "" {
+		description += " " + d.Location
+	}
+	if d.Overridden {
+		description += ", dependency_overrides"
+	}
+	return description
+}
+
+// GetPatrolDependency reads the patrol version from pubspec.lock,
+// falling back to `flutter pub deps` when the lockfile can't be used.
+func GetPatrolDependency(lockPath string, cmd commands.Command) (*PatrolDependency, error) {
+	dependency, err := GetPatrolDependencyFromLock(lockPath)
+	if err == nil {
+		return dependency, nil
+	}
+
+	print.Warning(fmt.Sprintf("Could not read Patrol from %s (%s), falling back to flutter pub deps", lockPath, err))
+	version, err := GetPatrolVersion(cmd)
+	if err != nil {
+		return nil, err
+	}
+	return &PatrolDependency{Version: version, Source: SourcePubDeps}, nil
+}
+
+// GetPatrolDependencyFromLock returns the patrol version and source recorded in pubspec.lock.
+// Relative path dependencies are resolved against the directory of the lockfile.
+func GetPatrolDependencyFromLock(lockPath string) (*PatrolDependency, error) {
+	lockfile, err := pubspec.ReadLockfile(lockPath)
+	if err != nil {
+		return nil, err
+	}
+	return PatrolDependencyFromLockfile(lockfile, filepath.Dir(lockPath))
+}
+
+// PatrolDependencyFromLockfile extracts the patrol package from a parsed lockfile.
+// For path dependencies the version is read from the package's own pubspec.yaml,
+// since that is what the build will actually use.
+func PatrolDependencyFromLockfile(lockfile *pubspec.Lockfile, projectDir string) (*PatrolDependency, error) {
+	pkg, ok := lockfile.Package(patrolPackage)
+	if !ok {
+		return nil, fmt.Errorf("patrol package not found")
+	}
+
+	dependency := &PatrolDependency{
+		Source:     pkg.Source,
+		Overridden: pkg.Dependency == overriddenDependency,
+	}
+
+	rawVersion := pkg.Version
+	switch pkg.Source {
+	case pubspec.SourceGit:
+		dependency.Location = pkg.Description.URL
+		if ref := gitRef(pkg.Description); ref != "" {
+			dependency.Location += "@" + ref
+		}
+	case pubspec.SourcePath:
+		packageDir := pkg.Description.Path
+		if pkg.Description.Relative || !filepath.IsAbs(packageDir) {
+			packageDir = filepath.Join(projectDir, packageDir)
+		}
+		dependency.Location = packageDir
+
+		packagePubspec, err := pubspec.ReadPubspec(filepath.Join(packageDir, pubspec.FileName))
+		if err != nil {
+			return nil, fmt.Errorf("could not read patrol path dependency: %w", err)
+		}
+		rawVersion = packagePubspec.Version
+	}
+
+	version, err := v.NewVersion(rawVersion)
+	if err != nil {
+		return nil, fmt.Errorf("invalid version format: %v", err)
+	}
+	dependency.Version = version
+
+	return dependency, nil
+}
+
+func gitRef(description pubspec.LockDescription) string {
+	if description.Ref != "" {
+		return description.Ref
+	}
+	if len(description.ResolvedRef) > 7 {
+		return description.ResolvedRef[:7]
+	}
+	return description.ResolvedRef
+}
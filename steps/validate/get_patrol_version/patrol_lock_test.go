@@ -0,0 +1,184 @@
+package get_patrol_version
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"patrol_install/commands"
+	"patrol_install/utils/pubspec"
+)
+
+func writeLockfile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), pubspec.LockFileName)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write lockfile: %v", err)
+	}
+	return path
+}
+
+func Test_GetPatrolDependencyFromLock(t *testing.T) {
+	tests := []struct {
+		name       string
+		lock       string
+		want       string
+		wantSource string
+		wantErr    bool
+	}{
+		{
+			name: "hosted_patrol",
+			lock: `packages:
+  patrol:
+    dependency: "direct dev"
+    description:
+      name: patrol
+      url: "https://pub.dev"
+    source: hosted
+    version: "3.15.1"
+`,
+			want:       "3.15.1",
+			wantSource: pubspec.SourceHosted,
+		},
+		{
+			name: "patrol_finders_only",
+			lock: `packages:
+  patrol_finders:
+    dependency: transitive
+    description:
+      name: patrol_finders
+      url: "https://pub.dev"
+    source: hosted
+    version: "2.7.2"
+`,
+			wantErr: true,
+		},
+		{
+			name: "invalid_version",
+			lock: `packages:
+  patrol:
+    dependency: "direct dev"
+    description:
+      name: patrol
+      url: "https://pub.dev"
+    source: hosted
+    version: "latest"
+`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := GetPatrolDependencyFromLock(writeLockfile(t, tt.lock))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if got.Version.String() != tt.want || got.Source != tt.wantSource {
+				t.Errorf("got %s (%s), want %s (%s)", got.Version, got.Source, tt.want, tt.wantSource)
+			}
+		})
+	}
+}
+
+func Test_GetPatrolDependency_FallbackRequiresPubDepsCommand(t *testing.T) {
+	// GIVEN no lockfile and a wrong fallback command
+	missing := filepath.Join(t.TempDir(), pubspec.LockFileName)
+	wrongCmd := commands.Command{Name: "echo", Args: []string{"hello"}}
+
+	// WHEN resolving the dependency
+	_, err := GetPatrolDependency(missing, wrongCmd)
+
+	// THEN the fallback error is returned
+	if err == nil {
+		t.Fatal("expected error, got nil")
+	}
+}
+
+func Test_GetPatrolDependencyFromLock_GitSource(t *testing.T) {
+	lock := `packages:
+  patrol:
+    dependency: "direct dev"
+    description:
+      path: "packages/patrol"
+      ref: fix-ios-timeouts
+      resolved-ref: "0123456789abcdef0123456789abcdef01234567"
+      url: "https://github.com/acme/patrol.git"
+    source: git
+    version: "3.15.1"
+`
+	got, err := GetPatrolDependencyFromLock(writeLockfile(t, lock))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got.Version.String() != "3.15.1" || got.Source != pubspec.SourceGit || got.IsHosted() {
+		t.Errorf("unexpected dependency %+v", got)
+	}
+	if got.Location != "https://github.com/acme/patrol.git@fix-ios-timeouts" {
+		t.Errorf("unexpected location %q", got.Location)
+	}
+}
+
+func Test_GetPatrolDependencyFromLock_OverriddenPathSource(t *testing.T) {
+	// GIVEN patrol overridden with a local checkout whose pubspec declares another version
+	root := t.TempDir()
+	projectDir := filepath.Join(root, "app")
+	packageDir := filepath.Join(root, "patrol")
+	for _, dir := range []string{projectDir, packageDir} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatalf("failed to create %s: %v", dir, err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(packageDir, pubspec.FileName), []byte("name: patrol\nversion: 3.16.0-dev.2\n"), 0644); err != nil {
+		t.Fatalf("failed to write pubspec: %v", err)
+	}
+
+	lock := `packages:
+  patrol:
+    dependency: "direct overridden"
+    description:
+      path: "../patrol"
+      relative: true
+    source: path
+    version: "3.15.1"
+`
+	lockPath := filepath.Join(projectDir, pubspec.LockFileName)
+	if err := os.WriteFile(lockPath, []byte(lock), 0644); err != nil {
+		t.Fatalf("failed to write lockfile: %v", err)
+	}
+
+	// WHEN resolving the dependency
+	got, err := GetPatrolDependencyFromLock(lockPath)
+
+	// THEN the version comes from the package pubspec
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got.Version.String() != "3.16.0-dev.2" {
+		t.Errorf("expected version from the package pubspec, got %s", got.Version)
+	}
+	if !got.Overridden || got.Source != pubspec.SourcePath {
+		t.Errorf("expected overridden path dependency, got %+v", got)
+	}
+	if got.Description() != "path "+packageDir+", dependency_overrides" {
+		t.Errorf("unexpected description %q", got.Description())
+	}
+}
+
+func Test_GetPatrolDependencyFromLock_MissingPathPackage(t *testing.T) {
+	lock := `packages:
+  patrol:
+    dependency: "direct dev"
+    description:
+      path: "../missing_patrol"
+      relative: true
+    source: path
+    version: "3.15.1"
+`
+	if _, err := GetPatrolDependencyFromLock(writeLockfile(t, lock)); err == nil {
+		t.Fatal("expected error for missing path package")
+	}
+}
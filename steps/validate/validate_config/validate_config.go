@@ -0,0 +1,191 @@
+package validate_config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	build_constants "patrol_install/steps/build/constants"
+	"patrol_install/utils/pubspec"
+)
+
+const PatrolJUnitRunner = "pl.leancode.patrol.PatrolJUnitRunner"
+
+const uiTestsTarget = "RunnerUITests"
+
+var androidBuildFiles = []string{
+	filepath.Join("android", "app", "build.gradle"),
+	filepath.Join("android", "app", "build.gradle.kts"),
+}
+
+var iosProjectFile = filepath.Join("ios", "Runner.xcodeproj", "project.pbxproj")
+
+// Matches both `testInstrumentationRunner "..."` (Groovy) and `testInstrumentationRunner = "..."` (Kotlin).
+var instrumentationRunner = regexp.MustCompile(`testInstrumentationRunner\s*=?\s*\(?\s*["']([^"']+)["']`)
+
+var nativeTargetSection = regexp.MustCompile(`(?s)/\* Begin PBXNativeTarget section \*/(.*?)/\* End PBXNativeTarget section \*/`)
+
+// Problem is a configuration mistake found in a project file, with the change that fixes it.
+type Problem struct {
+	File    string
+	Message string
+	Fix     string
+}
+
+func (p Problem) String() string {
+	return fmt.Sprintf("%s: %s\n    Fix: %s", p.File, p.Message, p.Fix)
+}
+
+// CheckProject statically checks the Patrol configuration of the Flutter project in projectDir
+// for the given PLATFORM value (android, ios or both).
+func CheckProject(projectDir, platform string) []Problem {
+	android, ios := platformsFor(platform)
+
+	problems := checkPubspec(projectDir, android, ios)
+	if android {
+		problems = append(problems, checkAndroidRunner(projectDir)...)
+	}
+	if ios {
+		problems = append(problems, checkIOSTarget(projectDir)...)
+	}
+	return problems
+}
+
+func platformsFor(platform string) (android bool, ios bool) {
+	switch strings.ToLower(strings.TrimSpace(platform)) {
+	case build_constants.PlatformAndroid:
+		return true, false
+	case build_constants.PlatformIOS:
+		return false, true
+	default:
+		return true, true
+	}
+}
+
+func checkPubspec(projectDir string, android, ios bool) []Problem {
+	config, err := pubspec.ReadPubspec(filepath.Join(projectDir, pubspec.FileName))
+	if err != nil {
+		return []Problem{{
+			File:    pubspec.FileName,
+			Message: err.Error(),
+			Fix:     "run the step from the root of the Flutter project",
+		}}
+	}
+
+	if config.Patrol == nil {
+		return []Problem{{
+			File:    pubspec.FileName,
+			Message: "the `patrol:` section is missing",
+			Fix:     "add a `patrol:` section with app_name" + platformKeys(android, ios),
+		}}
+	}
+
+	var problems []Problem
+	missing := func(key, example string) {
+		problems = append(problems, Problem{
+			File:    pubspec.FileName,
+			Message: fmt.Sprintf("`patrol.%s` is missing", key),
+			Fix:     fmt.Sprintf("set `%s` in the `patrol:` section, e.g. %s", key, example),
+		})
+	}
+
+	if strings.TrimSpace(config.Patrol.AppName) == "" {
+		missing("app_name", "`app_name: My App`")
+	}
+	if android && strings.TrimSpace(config.Patrol.Android.PackageName) == "" {
+		missing("android.package_name", "`package_name: com.example.app` under `android:`")
+	}
+	if ios && strings.TrimSpace(config.Patrol.IOS.BundleID) == "" {
+		missing("ios.bundle_id", "`bundle_id: com.example.App` under `ios:`")
+	}
+	return problems
+}
+
+func platformKeys(android, ios bool) string {
+	var keys []string
+	if android {
+		keys = append(keys, "android.package_name")
+	}
+	if ios {
+		keys = append(keys, "ios.bundle_id")
+	}
+	if len(keys) == 0 {
+		return ""
+	}
+	return ", " + strings.Join(keys, " and ")
+}
+
+func checkAndroidRunner(projectDir string) []Problem {
+	path, data, err := readFirst(projectDir, androidBuildFiles)
+	if err != nil {
+		return []Problem{{
+			File:    androidBuildFiles[0],
+			Message: "no android/app/build.gradle or build.gradle.kts found",
+			Fix:     "make sure the Android app module exists, or set PLATFORM to ios",
+		}}
+	}
+
+	fix := fmt.Sprintf("add `testInstrumentationRunner \"%s\"` to defaultConfig", PatrolJUnitRunner)
+	if strings.HasSuffix(path, ".kts") {
+		fix = fmt.Sprintf("add `testInstrumentationRunner = \"%s\"` to defaultConfig", PatrolJUnitRunner)
+	}
+
+	match := instrumentationRunner.FindStringSubmatch(string(data))
+	if match == nil {
+		return []Problem{{File: path, Message: "testInstrumentationRunner is not set", Fix: fix}}
+	}
+	if match[1] != PatrolJUnitRunner {
+		return []Problem{{
+			File:    path,
+			Message: fmt.Sprintf("testInstrumentationRunner is %q, Patrol tests need %q", match[1], PatrolJUnitRunner),
+			Fix:     "replace it in defaultConfig with " + PatrolJUnitRunner,
+		}}
+	}
+	return nil
+}
+
+func checkIOSTarget(projectDir string) []Problem {
+	data, err := os.ReadFile(filepath.Join(projectDir, iosProjectFile))
+	if err != nil {
+		return []Problem{{
+			File:    iosProjectFile,
+			Message: "the Xcode project was not found",
+			Fix:     "make sure the iOS Runner project exists, or set PLATFORM to android",
+		}}
+	}
+
+	if hasNativeTarget(string(data), uiTestsTarget) {
+		return nil
+	}
+	return []Problem{{
+		File:    iosProjectFile,
+		Message: "the " + uiTestsTarget + " target is missing",
+		Fix:     "add a UI Testing Bundle target named " + uiTestsTarget + " in Xcode, as described in the Patrol iOS setup guide",
+	}}
+}
+
+// hasNativeTarget looks for the target in the PBXNativeTarget section, or anywhere when the section markers are missing.
+func hasNativeTarget(project, name string) bool {
+	section := project
+	if match := nativeTargetSection.FindStringSubmatch(project); match != nil {
+		section = match[1]
+	}
+	return regexp.MustCompile(`name = "?` + regexp.QuoteMeta(name) + `"?;`).MatchString(section)
+}
+
+// readFirst returns the path, relative to projectDir, and content of the first file that exists.
+func readFirst(projectDir string, paths []string) (string, []byte, error) {
+	for _, path := range paths {
+		data, err := os.ReadFile(filepath.Join(projectDir, path))
+		if err == nil {
+			return path, data, nil
+		}
+		if !errors.Is(err, os.ErrNotExist) {
+			return path, nil, err
+		}
+	}
+	return "", nil, os.ErrNotExist
+}
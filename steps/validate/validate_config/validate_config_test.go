@@ -0,0 +1,177 @@
+package validate_config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const validPubspec = `name: example
+patrol:
+  app_name: Example
+  android:
+    package_name: com.example.app
+  ios:
+    bundle_id: com.example.App
+`
+
+const groovyBuildGradle = `android {
+    defaultConfig {
+        applicationId "com.example.app"
+        testInstrumentationRunner "pl.leancode.patrol.PatrolJUnitRunner"
+    }
+}
+`
+
+const kotlinBuildGradle = `android {
+    defaultConfig {
+        applicationId = "com.example.app"
+        testInstrumentationRunner = "pl.leancode.patrol.PatrolJUnitRunner"
+    }
+}
+`
+
+const projectWithUITests = `/* Begin PBXNativeTarget section */
+		97C146ED1CF9000F007C117D /* Runner */ = {
+			isa = PBXNativeTarget;
+			name = Runner;
+		};
+		D7E2A3F42A0B000000000001 /* RunnerUITests */ = {
+			isa = PBXNativeTarget;
+			name = RunnerUITests;
+		};
+/* End PBXNativeTarget section */
+`
+
+const projectWithoutUITests = `/* Begin PBXNativeTarget section */
+		97C146ED1CF9000F007C117D /* Runner */ = {
+			isa = PBXNativeTarget;
+			name = Runner;
+		};
+/* End PBXNativeTarget section */
+/* Begin XCBuildConfiguration section */
+			name = RunnerUITests;
+/* End XCBuildConfiguration section */
+`
+
+func writeProject(t *testing.T, files map[string]string) string {
+	t.Helper()
+	root := t.TempDir()
+	for path, content := range files {
+		full := filepath.Join(root, path)
+		if err := os.MkdirAll(filepath.Dir(full), 0755); err != nil {
+			t.Fatalf("failed to create dir: %v", err)
+		}
+		if err := os.WriteFile(full, []byte(content), 0644); err != nil {
+			t.Fatalf("failed to write %s: %v", path, err)
+		}
+	}
+	return root
+}
+
+func validFiles() map[string]string {
+	return map[string]string{
+		"pubspec.yaml":             validPubspec,
+		"android/app/build.gradle": groovyBuildGradle,
+		iosProjectFile:             projectWithUITests,
+	}
+}
+
+func TestCheckProject_Valid(t *testing.T) {
+	root := writeProject(t, validFiles())
+
+	if problems := CheckProject(root, "both"); len(problems) != 0 {
+		t.Fatalf("expected no problems, got %v", problems)
+	}
+}
+
+func TestCheckProject_KotlinBuildFile(t *testing.T) {
+	files := validFiles()
+	delete(files, "android/app/build.gradle")
+	files["android/app/build.gradle.kts"] = kotlinBuildGradle
+	root := writeProject(t, files)
+
+	if problems := CheckProject(root, "android"); len(problems) != 0 {
+		t.Fatalf("expected no problems, got %v", problems)
+	}
+}
+
+func TestCheckProject_MissingPatrolSection(t *testing.T) {
+	files := validFiles()
+	files["pubspec.yaml"] = "name: example\n"
+	root := writeProject(t, files)
+
+	problems := CheckProject(root, "ios")
+	if len(problems) != 1 || !strings.Contains(problems[0].Message, "`patrol:` section is missing") {
+		t.Fatalf("expected a missing section problem, got %v", problems)
+	}
+	if !strings.Contains(problems[0].Fix, "ios.bundle_id") || strings.Contains(problems[0].Fix, "android") {
+		t.Errorf("expected the fix to mention only iOS keys, got %q", problems[0].Fix)
+	}
+}
+
+func TestCheckProject_MissingKeysPerPlatform(t *testing.T) {
+	files := validFiles()
+	files["pubspec.yaml"] = "name: example\npatrol:\n  android:\n    package_name: com.example.app\n"
+	root := writeProject(t, files)
+
+	android := CheckProject(root, "android")
+	if len(android) != 1 || !strings.Contains(android[0].Message, "patrol.app_name") {
+		t.Fatalf("expected only app_name to be missing for android, got %v", android)
+	}
+
+	ios := CheckProject(root, "ios")
+	if len(ios) != 2 || !strings.Contains(ios[1].Message, "patrol.ios.bundle_id") {
+		t.Fatalf("expected app_name and bundle_id to be missing for ios, got %v", ios)
+	}
+}
+
+func TestCheckProject_AndroidRunner(t *testing.T) {
+	t.Run("missing", func(t *testing.T) {
+		files := validFiles()
+		files["android/app/build.gradle"] = "android {\n    defaultConfig {}\n}\n"
+		root := writeProject(t, files)
+
+		problems := CheckProject(root, "android")
+		if len(problems) != 1 || !strings.Contains(problems[0].Fix, PatrolJUnitRunner) {
+			t.Fatalf("expected a missing runner problem, got %v", problems)
+		}
+	})
+
+	t.Run("wrong_runner", func(t *testing.T) {
+		files := validFiles()
+		files["android/app/build.gradle"] = `testInstrumentationRunner "androidx.test.runner.AndroidJUnitRunner"`
+		root := writeProject(t, files)
+
+		problems := CheckProject(root, "android")
+		if len(problems) != 1 || !strings.Contains(problems[0].Message, "androidx.test.runner.AndroidJUnitRunner") {
+			t.Fatalf("expected a wrong runner problem, got %v", problems)
+		}
+	})
+
+	t.Run("no_build_file", func(t *testing.T) {
+		files := validFiles()
+		delete(files, "android/app/build.gradle")
+		root := writeProject(t, files)
+
+		if problems := CheckProject(root, "android"); len(problems) != 1 {
+			t.Fatalf("expected a missing build file problem, got %v", problems)
+		}
+	})
+}
+
+func TestCheckProject_IOSTarget(t *testing.T) {
+	files := validFiles()
+	files[iosProjectFile] = projectWithoutUITests
+	root := writeProject(t, files)
+
+	problems := CheckProject(root, "ios")
+	if len(problems) != 1 || !strings.Contains(problems[0].Message, "RunnerUITests") {
+		t.Fatalf("expected a missing target problem, got %v", problems)
+	}
+
+	if problems := CheckProject(root, "android"); len(problems) != 0 {
+		t.Fatalf("expected iOS to be skipped for android, got %v", problems)
+	}
+}
@@ -14,7 +14,7 @@ func TestCheckCompatibilityErrorFormat(t *testing.T) {
 		PatrolVersion:  v.MustParse("5.0.0"),
 	}
 
-	isCompatible := CheckCompatibility(params)
+	isCompatible, _ := CheckCompatibility(params)
 	if isCompatible {
 		t.Error("CheckCompatibility() expected false for incompatible versions, got true")
 	}
@@ -38,7 +38,7 @@ func TestCheckCompatibilityWithNilVersions(t *testing.T) {
 			PatrolVersion:  v.MustParse("4.0.0"),
 		}
 
-		_ = CheckCompatibility(params)
+		_, _ = CheckCompatibility(params)
 	})
 
 	t.Run("nil_cli_version", func(t *testing.T) {
@@ -54,7 +54,7 @@ func TestCheckCompatibilityWithNilVersions(t *testing.T) {
 			PatrolVersion:  v.MustParse("4.0.0"),
 		}
 
-		_ = CheckCompatibility(params)
+		_, _ = CheckCompatibility(params)
 	})
 
 	t.Run("nil_patrol_version", func(t *testing.T) {
@@ -70,6 +70,6 @@ func TestCheckCompatibilityWithNilVersions(t *testing.T) {
 			PatrolVersion:  nil,
 		}
 
-		_ = CheckCompatibility(params)
+		_, _ = CheckCompatibility(params)
 	})
 }
@@ -1,16 +1,35 @@
 package validate_versions
 
 import (
+	"fmt"
+
 	v "github.com/Masterminds/semver/v3"
+
+	cli_constraint "patrol_install/steps/install_patrol_cli/cli_constraint"
+	"patrol_install/utils/pubspec"
 )
 
 type ValidateRunParams struct {
 	FlutterVersion *v.Version
 	CliVersion     *v.Version
 	PatrolVersion  *v.Version
+	// PatrolSource is the pub source of the patrol package, empty is treated as hosted.
+	PatrolSource string
+	// FlutterChannel is the Flutter channel (stable, beta, master), empty when unknown.
+	FlutterChannel string
+	// DartVersion is the Dart SDK version, nil skips the Dart check.
+	DartVersion *v.Version
 }
 
-func CheckCompatibility(params ValidateRunParams) bool {
+// CheckCompatibility reports whether the versions match an entry of the CompatibilityTable,
+// along with the warnings the caller should print.
+//
+// The table only covers published releases. A patrol package coming from a git or path source
+// is a development snapshot whose declared version may not match any release, so for those
+// sources a combination outside the table is accepted with a warning instead of failing.
+//
+// Flutter pre-releases are compared by their release version, see meetsFlutterMinimum.
+func CheckCompatibility(params ValidateRunParams) (bool, []string) {
 	flutterV := params.FlutterVersion
 	patrolCLIV := params.CliVersion
 	patrolV := params.PatrolVersion
@@ -25,14 +44,147 @@ func CheckCompatibility(params ValidateRunParams) bool {
 		panic("PatrolVersion cannot be nil in CheckCompatibility")
 	}
 
+	var warnings []string
+	if warning := preReleaseWarning(flutterV, params.FlutterChannel); warning != "" {
+		warnings = append(warnings, warning)
+	}
+
+	var dartMismatch *VersionRange
+	for _, entry := range CompatibilityTable {
+		if !isVersionInRange(patrolCLIV, entry.PatrolCLIRange) ||
+			!isVersionInRange(patrolV, entry.PatrolRange) ||
+			!meetsFlutterMinimum(flutterV, entry.FlutterVersion) {
+			continue
+		}
+		if meetsDartRange(params.DartVersion, entry.DartRange) {
+			return true, warnings
+		}
+		dartMismatch = entry.DartRange
+	}
+
+	// A Dart SDK outside of the CLI requirement fails whatever the patrol source
+	if dartMismatch != nil {
+		warnings = append(warnings, fmt.Sprintf("⚠️ Dart SDK %s does not match Patrol CLI %s, which requires Dart %s",
+			params.DartVersion.String(), patrolCLIV.String(), dartMismatch.Describe()))
+		return false, warnings
+	}
+
+	if IsNonHostedSource(params.PatrolSource) {
+		warnings = append(warnings, fmt.Sprintf("⚠️ Patrol %s from a %s source is not in the compatibility table, compatibility is not guaranteed",
+			patrolV.String(), params.PatrolSource))
+		return true, warnings
+	}
+
+	return false, warnings
+}
+
+// CheckDartForCLI reports whether the Dart SDK can activate a Patrol CLI matching the constraint,
+// along with the lowest Dart SDK range required by the matching table entries.
+// Requests outside the table are not known to have a requirement and are reported as compatible.
+func CheckDartForCLI(dartV *v.Version, constraint *cli_constraint.CLIConstraint) (bool, *VersionRange) {
+	var required *VersionRange
+	for _, entry := range CompatibilityTable {
+		if entry.DartRange == nil || !constraintMatchesRange(constraint, entry.PatrolCLIRange) {
+			continue
+		}
+		if meetsDartRange(dartV, entry.DartRange) {
+			return true, entry.DartRange
+		}
+		if required == nil || entry.DartRange.Min.LessThan(required.Min) {
+			required = entry.DartRange
+		}
+	}
+
+	if required == nil {
+		return true, nil
+	}
+	return false, required
+}
+
+// LatestCLIVersion returns the newest Patrol CLI release of the CompatibilityTable.
+func LatestCLIVersion() *v.Version {
+	var latest *v.Version
 	for _, entry := range CompatibilityTable {
-		if isVersionInRange(patrolCLIV, entry.PatrolCLIRange) &&
-			isVersionInRange(patrolV, entry.PatrolRange) &&
-			flutterV.GreaterThanEqual(entry.FlutterVersion) {
-			return true
+		if latest == nil || entry.PatrolCLIRange.Max.GreaterThan(latest) {
+			latest = entry.PatrolCLIRange.Max
 		}
 	}
-	return false
+	return latest
+}
+
+// constraintMatchesRange reports whether the versions allowed by the constraint and the range intersect,
+// e.g. ">=3.9.5 <3.9.8" matches the range 3.9.0 - 3.10.0 although neither end satisfies it.
+func constraintMatchesRange(constraint *cli_constraint.CLIConstraint, r VersionRange) bool {
+	lower, upper := constraint.Interval()
+	if lower.Version != nil && (lower.Version.GreaterThan(r.Max) || lower.Version.Equal(r.Max) && !lower.Inclusive) {
+		return false
+	}
+	if upper.Version != nil && (upper.Version.LessThan(r.Min) || upper.Version.Equal(r.Min) && !upper.Inclusive) {
+		return false
+	}
+	if lower.Version == nil || upper.Version == nil {
+		return true
+	}
+	// Contradicting terms such as ">=4.0.0 <3.0.0" allow no version at all
+	return lower.Version.LessThan(upper.Version) ||
+		lower.Version.Equal(upper.Version) && lower.Inclusive && upper.Inclusive
+}
+
+// meetsDartRange reports whether the Dart SDK satisfies the range. Like Flutter, Dart
+// pre-releases are compared by their release core. A nil version or range always matches.
+func meetsDartRange(dartV *v.Version, r *VersionRange) bool {
+	if dartV == nil || r == nil {
+		return true
+	}
+	core := releaseCore(dartV)
+	if core.LessThan(r.Min) {
+		return false
+	}
+	return r.Max == nil || !core.GreaterThan(r.Max)
+}
+
+// IsNonHostedSource reports whether the patrol package source is exempt from strict table checks.
+func IsNonHostedSource(source string) bool {
+	return source == pubspec.SourceGit || source == pubspec.SourcePath
+}
+
+// meetsFlutterMinimum reports whether the Flutter version satisfies the minimum of a table entry.
+//
+// Semver orders pre-releases below their release, so 3.32.0-0.1.pre < 3.32.0 and a beta SDK
+// would fail a 3.32.0 minimum although it already ships the 3.32 framework. The rule applied
+// here is that X.Y.Z-pre is compared by its release core X.Y.Z, i.e. it satisfies minimums
+// up to and including X.Y.Z, and build metadata is ignored.
+func meetsFlutterMinimum(flutterV, minimum *v.Version) bool {
+	return releaseCore(flutterV).GreaterThanEqual(minimum)
+}
+
+// preReleaseWarning describes how a pre-release version or a non-stable channel is checked,
+// empty for a stable release.
+func preReleaseWarning(flutterV *v.Version, channel string) string {
+	if flutterV.Prerelease() != "" {
+		return fmt.Sprintf("⚠️ Flutter %s%s is a pre-release, compatibility is checked against Flutter %s",
+			flutterV.String(), channelSuffix(channel), releaseCore(flutterV).String())
+	}
+	if !isStableChannel(channel) {
+		return fmt.Sprintf("⚠️ Flutter %s is on the %s channel, compatibility is checked as for the stable release",
+			flutterV.String(), channel)
+	}
+	return ""
+}
+
+func releaseCore(version *v.Version) *v.Version {
+	return v.New(version.Major(), version.Minor(), version.Patch(), "", "")
+}
+
+func isStableChannel(channel string) bool {
+	return channel == "" || channel == "stable"
+}
+
+func channelSuffix(channel string) string {
+	if channel == "" {
+		return ""
+	}
+	return " (" + channel + " channel)"
 }
 
 func isVersionInRange(v *v.Version, r VersionRange) bool {
@@ -4,6 +4,8 @@ import (
 	"testing"
 
 	v "github.com/Masterminds/semver/v3"
+
+	cli_constraint "patrol_install/steps/install_patrol_cli/cli_constraint"
 )
 
 // TestIsVersionInRangeFunction tests the isVersionInRange function with various scenarios.
@@ -249,7 +251,7 @@ func TestTableCheckCompatibility(t *testing.T) {
 				PatrolVersion:  v.MustParse(tt.patrolVersion),
 			}
 
-			got := CheckCompatibility(params)
+			got, _ := CheckCompatibility(params)
 
 			t.Logf("📝 %s\n  Flutter: %s\n  Patrol CLI: %s\n  Patrol: %s\n  Expected: %v\n  Got: %v\n  Context: %s\n",
 				tt.name, tt.flutterVersion, tt.patrolCLIVersion, tt.patrolVersion, tt.areCompatible, got, tt.context)
@@ -261,3 +263,211 @@ func TestTableCheckCompatibility(t *testing.T) {
 		})
 	}
 }
+
+// TestCheckCompatibilityNonHostedSourcePolicy tests that git and path patrol sources outside the table only warn.
+func TestCheckCompatibilityNonHostedSourcePolicy(t *testing.T) {
+	tests := []struct {
+		name          string
+		source        string
+		areCompatible bool
+	}{
+		{name: "empty_source_is_strict", source: "", areCompatible: false},
+		{name: "hosted_source_is_strict", source: "hosted", areCompatible: false},
+		{name: "pub_deps_source_is_strict", source: "pub deps", areCompatible: false},
+		{name: "git_source_is_lenient", source: "git", areCompatible: true},
+		{name: "path_source_is_lenient", source: "path", areCompatible: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			params := ValidateRunParams{
+				FlutterVersion: v.MustParse("3.32.0"),
+				CliVersion:     v.MustParse("4.0.1"),
+				PatrolVersion:  v.MustParse("4.2.0-dev.1"),
+				PatrolSource:   tt.source,
+			}
+			if got, _ := CheckCompatibility(params); got != tt.areCompatible {
+				t.Errorf("CheckCompatibility() with source %q = %v, want %v", tt.source, got, tt.areCompatible)
+			}
+		})
+	}
+}
+
+// TestCheckCompatibilityDartVersion tests that the Dart SDK is checked when it is known.
+func TestCheckCompatibilityDartVersion(t *testing.T) {
+	params := ValidateRunParams{
+		FlutterVersion: v.MustParse("3.32.0"),
+		CliVersion:     v.MustParse("4.0.1"),
+		PatrolVersion:  v.MustParse("4.0.0"),
+	}
+
+	if ok, _ := CheckCompatibility(params); !ok {
+		t.Error("expected compatibility without a Dart SDK version")
+	}
+
+	params.DartVersion = v.MustParse("3.8.1")
+	if ok, _ := CheckCompatibility(params); !ok {
+		t.Error("expected Dart 3.8.1 to be compatible")
+	}
+
+	params.DartVersion = v.MustParse("3.7.2")
+	ok, warnings := CheckCompatibility(params)
+	if ok {
+		t.Error("expected Dart 3.7.2 to be incompatible with Patrol CLI 4.0.1")
+	}
+	want := "⚠️ Dart SDK 3.7.2 does not match Patrol CLI 4.0.1, which requires Dart 3.8.0 or newer"
+	if len(warnings) != 1 || warnings[0] != want {
+		t.Errorf("warnings = %q, want [%q]", warnings, want)
+	}
+}
+
+// TestCheckDartForCLI tests the Dart SDK check done before activating the Patrol CLI.
+func TestCheckDartForCLI(t *testing.T) {
+	tests := []struct {
+		name         string
+		dart         string
+		constraint   string
+		isCompatible bool
+		required     string
+	}{
+		{name: "exact_version_ok", dart: "3.8.0", constraint: "4.0.1", isCompatible: true, required: "3.8.0"},
+		{name: "exact_version_too_old", dart: "3.5.4", constraint: "4.0.1", isCompatible: false, required: "3.8.0"},
+		{name: "exact_version_inside_range", dart: "3.5.4", constraint: "3.9.5", isCompatible: false, required: "3.8.0"},
+		{name: "caret_allows_older_release", dart: "3.5.4", constraint: "^3.5.0", isCompatible: true, required: "3.5.0"},
+		{name: "range_too_old", dart: "3.2.6", constraint: ">=3.7.0 <4.0.0", isCompatible: false, required: "3.8.0"},
+		{name: "range_inside_table_range", dart: "3.5.4", constraint: ">=3.9.5 <3.9.8", isCompatible: false, required: "3.8.0"},
+		{name: "dart_dev_build_uses_release_core", dart: "3.8.0-265.0.dev", constraint: "4.0.1", isCompatible: true, required: "3.8.0"},
+		{name: "unknown_cli_version", dart: "2.19.0", constraint: "9.0.0", isCompatible: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			constraint, err := cli_constraint.Parse(tt.constraint)
+			if err != nil {
+				t.Fatalf("invalid constraint %q: %v", tt.constraint, err)
+			}
+
+			isCompatible, required := CheckDartForCLI(v.MustParse(tt.dart), constraint)
+			if isCompatible != tt.isCompatible {
+				t.Errorf("CheckDartForCLI(%s, %s) = %v, want %v", tt.dart, tt.constraint, isCompatible, tt.isCompatible)
+			}
+			if tt.required == "" {
+				if required != nil {
+					t.Errorf("expected no required version, got %s", required)
+				}
+				return
+			}
+			if required == nil || required.Min.String() != tt.required {
+				t.Errorf("required = %v, want %s", required, tt.required)
+			}
+		})
+	}
+}
+
+// TestConstraintMatchesRange tests that constraints are intersected with the whole range, not only its ends.
+func TestConstraintMatchesRange(t *testing.T) {
+	r := VersionRange{Min: v.MustParse("3.9.0"), Max: v.MustParse("3.10.0")}
+	tests := []struct {
+		constraint string
+		want       bool
+	}{
+		{constraint: ">=3.9.5 <3.9.8", want: true},
+		{constraint: "3.9.5", want: true},
+		{constraint: "^3.0.0", want: true},
+		{constraint: ">3.10.0", want: false},
+		{constraint: ">=3.10.0", want: true},
+		{constraint: "<3.9.0", want: false},
+		{constraint: "<=3.9.0", want: true},
+		{constraint: ">=3.11.0 <3.9.5", want: false},
+	}
+
+	for _, tt := range tests {
+		constraint, err := cli_constraint.Parse(tt.constraint)
+		if err != nil {
+			t.Fatalf("invalid constraint %q: %v", tt.constraint, err)
+		}
+		if got := constraintMatchesRange(constraint, r); got != tt.want {
+			t.Errorf("constraintMatchesRange(%s, 3.9.0 - 3.10.0) = %v, want %v", tt.constraint, got, tt.want)
+		}
+	}
+}
+
+// TestCompatibilityTableDartRanges tests that every entry has a valid Dart range and that newer
+// Patrol CLI releases never require an older Dart SDK.
+func TestCompatibilityTableDartRanges(t *testing.T) {
+	var previous *CompatibilityEntry
+	for i := range CompatibilityTable {
+		entry := &CompatibilityTable[i]
+		r := entry.DartRange
+		if r == nil || r.Min == nil {
+			t.Errorf("Patrol CLI %s has no Dart SDK minimum", entry.PatrolCLIRange.Describe())
+			continue
+		}
+		if r.Max != nil && r.Max.LessThan(r.Min) {
+			t.Errorf("Patrol CLI %s has an empty Dart range %s", entry.PatrolCLIRange.Describe(), r.Describe())
+		}
+		if previous != nil && previous.DartRange != nil && previous.DartRange.Min.LessThan(r.Min) {
+			t.Errorf("Patrol CLI %s requires Dart %s, newer than Patrol CLI %s",
+				entry.PatrolCLIRange.Describe(), r.Describe(), previous.PatrolCLIRange.Describe())
+		}
+		previous = entry
+	}
+}
+
+// TestLatestCLIVersion tests that the newest release is taken from the whole table.
+func TestLatestCLIVersion(t *testing.T) {
+	if got := LatestCLIVersion(); !got.Equal(v.MustParse("4.0.1")) {
+		t.Errorf("LatestCLIVersion() = %s, want 4.0.1", got)
+	}
+}
+
+// TestMeetsFlutterMinimum tests the pre-release rule used for Flutter minimums.
+func TestMeetsFlutterMinimum(t *testing.T) {
+	tests := []struct {
+		flutter string
+		minimum string
+		want    bool
+	}{
+		{flutter: "3.32.0", minimum: "3.32.0", want: true},
+		{flutter: "3.32.0-0.1.pre", minimum: "3.32.0", want: true},
+		{flutter: "3.32.1-0.0.pre", minimum: "3.32.0", want: true},
+		{flutter: "3.32.0+hotfix.1", minimum: "3.32.0", want: true},
+		{flutter: "3.31.9-1.0.pre", minimum: "3.32.0", want: false},
+	}
+
+	for _, tt := range tests {
+		got := meetsFlutterMinimum(v.MustParse(tt.flutter), v.MustParse(tt.minimum))
+		if got != tt.want {
+			t.Errorf("meetsFlutterMinimum(%s, %s) = %v, want %v", tt.flutter, tt.minimum, got, tt.want)
+		}
+	}
+}
+
+// TestPreReleaseWarning tests that the warning describes the actual version and channel.
+func TestPreReleaseWarning(t *testing.T) {
+	tests := []struct {
+		name    string
+		flutter string
+		channel string
+		want    string
+	}{
+		{name: "stable", flutter: "3.32.0", channel: "stable", want: ""},
+		{name: "unknown_channel", flutter: "3.32.0", channel: "", want: ""},
+		{
+			name: "pre_release_version", flutter: "3.33.0-0.1.pre", channel: "beta",
+			want: "⚠️ Flutter 3.33.0-0.1.pre (beta channel) is a pre-release, compatibility is checked against Flutter 3.33.0",
+		},
+		{
+			name: "release_version_on_master", flutter: "3.32.0", channel: "master",
+			want: "⚠️ Flutter 3.32.0 is on the master channel, compatibility is checked as for the stable release",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := preReleaseWarning(v.MustParse(tt.flutter), tt.channel); got != tt.want {
+				t.Errorf("preReleaseWarning(%s, %q) = %q, want %q", tt.flutter, tt.channel, got, tt.want)
+			}
+		})
+	}
+}
@@ -6,18 +6,92 @@ import (
 
 	v "github.com/Masterminds/semver/v3"
 
+	cli_constraint "patrol_install/steps/install_patrol_cli/cli_constraint"
+	get_cli_version "patrol_install/steps/install_patrol_cli/get_cli_version"
+	dart "patrol_install/steps/validate/get_dart_version"
+	flutter "patrol_install/steps/validate/get_flutter_version"
+	patrol "patrol_install/steps/validate/get_patrol_version"
 	versions "patrol_install/steps/validate/validate_versions"
 	"patrol_install/utils/print"
 )
 
 type Validator interface {
-	GetFlutterVersion() (*v.Version, error)
-	GetPatrolVersion() (*v.Version, error)
+	GetFlutterVersion() (*flutter.FlutterInfo, error)
+	GetPatrolVersion() (*patrol.PatrolDependency, error)
+	GetDartVersion() (*dart.Detection, error)
+	GetPatrolCLIVersion() (*get_cli_version.Detection, error)
 }
 
 type ValidatorRunParams struct {
-	Runner     Validator
-	CliVersion *v.Version
+	Runner Validator
+	// CliDetection is the installed Patrol CLI version and how it was detected.
+	CliDetection *get_cli_version.Detection
+	// DartVersion is the version found by RunDartCheck, nil to fall back to the one reported by Flutter.
+	DartVersion *v.Version
+}
+
+type DartCheckRunParams struct {
+	Runner Validator
+	// RequestedCLIVersion is the version or constraint the Patrol CLI is about to be activated with.
+	RequestedCLIVersion string
+	// HostedSource is false when the Patrol CLI is activated from git or path, whose version isn't known ahead.
+	HostedSource bool
+}
+
+// RunDartCheck detects the Dart SDK and fails when it is too old for the Patrol CLI about to be
+// activated, so the problem is reported before `dart pub global activate` fails with a resolver error.
+// Without a requested version that is the latest release, see defaultActivation.
+// A Dart SDK that can't be detected is not an error.
+func RunDartCheck(params DartCheckRunParams) (*v.Version, error) {
+	print.StepInitiated("--- Getting Dart SDK Version ---")
+
+	detection, err := params.Runner.GetDartVersion()
+	if err != nil {
+		print.Warning("⚠️ " + err.Error() + ", skipping the Dart SDK check")
+		return nil, nil
+	}
+	dartVersion := detection.Version
+	print.StepCompleted("✅ Dart SDK Version: " + dartVersion.String() + " (detected via `" + detection.Method + "`)\n")
+
+	constraint, err := cli_constraint.Parse(params.RequestedCLIVersion)
+	if err != nil {
+		// Malformed requests are reported by the installer
+		return dartVersion, nil
+	}
+	target := "Patrol CLI " + params.RequestedCLIVersion
+	if constraint == nil {
+		if constraint = defaultActivation(params); constraint == nil {
+			return dartVersion, nil
+		}
+		target = "Patrol CLI " + constraint.Raw + " (the latest release, activated when CUSTOM_PATROL_CLI_VERSION is empty)"
+	}
+
+	isCompatible, required := versions.CheckDartForCLI(dartVersion, constraint)
+	if isCompatible {
+		return dartVersion, nil
+	}
+
+	errorMessage := fmt.Sprintf("❌ Dart SDK %s is too old for %s, which requires Dart %s. Upgrade Flutter or request an older Patrol CLI",
+		dartVersion.String(), target, required.Describe())
+	print.Error(errorMessage)
+	return dartVersion, errors.New(errorMessage)
+}
+
+// defaultActivation returns the Patrol CLI the installer activates when no version is requested,
+// nil when it activates none or its version isn't known ahead. An installed hosted CLI is kept,
+// otherwise pub activates the latest release, taken as the newest one of the compatibility table.
+func defaultActivation(params DartCheckRunParams) *cli_constraint.CLIConstraint {
+	if !params.HostedSource {
+		return nil
+	}
+	if _, err := params.Runner.GetPatrolCLIVersion(); err == nil {
+		return nil
+	}
+	constraint, err := cli_constraint.Parse(versions.LatestCLIVersion().String())
+	if err != nil {
+		return nil
+	}
+	return constraint
 }
 
 func Run(params ValidatorRunParams) error {
@@ -25,17 +99,19 @@ func Run(params ValidatorRunParams) error {
 
 	print.StepInitiated("--- Getting Flutter Version ---")
 
-	flutterVersion, err := runner.GetFlutterVersion()
+	flutterInfo, err := runner.GetFlutterVersion()
 	if err != nil {
 		print.Warning("❌ Failed to get Flutter version")
 		print.Error(err.Error())
 		return err
 	}
 
+	flutterVersion := flutterInfo.Version
+	printFlutterDetails(flutterInfo)
 	print.StepCompleted("✅ Flutter Version: " + flutterVersion.String() + "\n")
 
 	print.StepInitiated("--- Getting Patrol Version ---")
-	patrolVersion, patrolErr := runner.GetPatrolVersion()
+	patrolDependency, patrolErr := runner.GetPatrolVersion()
 
 	if patrolErr != nil {
 		print.Warning("❌ Failed to get Patrol version")
@@ -43,26 +119,69 @@ func Run(params ValidatorRunParams) error {
 		return patrolErr
 	}
 
-	print.StepCompleted("✅ Patrol Version: " + patrolVersion.String() + "\n")
+	patrolVersion := patrolDependency.Version
+	print.StepCompleted("✅ Patrol Version: " + patrolVersion.String() + " (source: " + patrolDependency.Description() + ")\n")
+
+	dartVersion := params.DartVersion
+	if dartVersion == nil && flutterInfo.DartSDKVersion != "" {
+		dartVersion, _ = dart.ParseDartSDKField(flutterInfo.DartSDKVersion)
+	}
 
 	validatorParams := versions.ValidateRunParams{
 		FlutterVersion: flutterVersion,
-		CliVersion:     params.CliVersion,
+		CliVersion:     params.CliDetection.Version,
 		PatrolVersion:  patrolVersion,
+		PatrolSource:   patrolDependency.Source,
+		FlutterChannel: flutterInfo.Channel,
+		DartVersion:    dartVersion,
 	}
 
 	print.StepInitiated("--- Checking Compatibility ---")
-	isCompatible := versions.CheckCompatibility(validatorParams)
+	isCompatible, warnings := versions.CheckCompatibility(validatorParams)
+	for _, warning := range warnings {
+		print.Warning(warning)
+	}
 
 	if isCompatible {
-		message := fmt.Sprintf("✅ Flutter %s, Patrol CLI %s and Patrol %s are compatible",
-			flutterVersion.String(), params.CliVersion.String(), patrolVersion.String())
+		message := fmt.Sprintf("✅ Flutter %s, Patrol CLI %s and Patrol %s are compatible%s",
+			flutterVersion.String(), cliDescription(params.CliDetection), patrolVersion.String(), dartSuffix(dartVersion))
 		print.StepCompleted(message)
 		return nil
 	}
-	errorMessage := fmt.Sprintf("❌ Flutter %s, Patrol CLI %s and Patrol %s are not compatible",
-		flutterVersion.String(), params.CliVersion.String(), patrolVersion.String())
+	errorMessage := fmt.Sprintf("❌ Flutter %s, Patrol CLI %s and Patrol %s are not compatible%s",
+		flutterVersion.String(), cliDescription(params.CliDetection), patrolVersion.String(), dartSuffix(dartVersion))
 	print.Error(errorMessage)
 	return errors.New(errorMessage)
+}
 
+// printFlutterDetails prints the SDK details that the detection method provided.
+func printFlutterDetails(info *flutter.FlutterInfo) {
+	details := []struct {
+		label string
+		value string
+	}{
+		{"Channel", info.Channel},
+		{"Framework revision", info.FrameworkRevision},
+		{"Engine revision", info.EngineRevision},
+		{"Dart SDK", info.DartSDKVersion},
+		{"Flutter root", info.Root},
+		{"Detected via", info.Method},
+	}
+	for _, detail := range details {
+		if detail.value != "" {
+			print.Vanilla(fmt.Sprintf("%s: %s", detail.label, detail.value))
+		}
+	}
+}
+
+// cliDescription is the Patrol CLI version with the method that detected it.
+func cliDescription(detection *get_cli_version.Detection) string {
+	return detection.Version.String() + " (detected via `" + detection.Method + "`)"
+}
+
+func dartSuffix(dartVersion *v.Version) string {
+	if dartVersion == nil {
+		return ""
+	}
+	return " (Dart SDK " + dartVersion.String() + ")"
 }
@@ -1,18 +1,27 @@
 package validate
 
 import (
-	v "github.com/Masterminds/semver/v3"
-
+	get_cli_version "patrol_install/steps/install_patrol_cli/get_cli_version"
+	dart "patrol_install/steps/validate/get_dart_version"
 	flutter "patrol_install/steps/validate/get_flutter_version"
 	patrol "patrol_install/steps/validate/get_patrol_version"
+	"patrol_install/utils/pubspec"
 )
 
 type ValidatorRunner struct{}
 
-func (p *ValidatorRunner) GetFlutterVersion() (*v.Version, error) {
-	return flutter.GetFlutterVersion(flutter.FlutterVersionCmd)
+func (p *ValidatorRunner) GetFlutterVersion() (*flutter.FlutterInfo, error) {
+	return flutter.GetFlutterInfo(nil)
+}
+
+func (p *ValidatorRunner) GetPatrolVersion() (*patrol.PatrolDependency, error) {
+	return patrol.GetPatrolDependency(pubspec.LockFileName, patrol.FlutterPubDepsCmd)
+}
+
+func (p *ValidatorRunner) GetDartVersion() (*dart.Detection, error) {
+	return dart.DetectDartVersion(nil)
 }
 
-func (p *ValidatorRunner) GetPatrolVersion() (*v.Version, error) {
-	return patrol.GetPatrolVersion(patrol.FlutterPubDepsCmd)
+func (p *ValidatorRunner) GetPatrolCLIVersion() (*get_cli_version.Detection, error) {
+	return get_cli_version.GetPatrolCLIVersion()
 }
@@ -0,0 +1,100 @@
+package validate
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	v "github.com/Masterminds/semver/v3"
+
+	get_cli_version "patrol_install/steps/install_patrol_cli/get_cli_version"
+	dart "patrol_install/steps/validate/get_dart_version"
+	flutter "patrol_install/steps/validate/get_flutter_version"
+	patrol "patrol_install/steps/validate/get_patrol_version"
+)
+
+type validatorStub struct {
+	dartVersion string
+	// installedCLI is the Patrol CLI already installed, empty when there is none.
+	installedCLI string
+}
+
+func (s *validatorStub) GetFlutterVersion() (*flutter.FlutterInfo, error) {
+	return nil, errors.New("not used")
+}
+
+func (s *validatorStub) GetPatrolVersion() (*patrol.PatrolDependency, error) {
+	return nil, errors.New("not used")
+}
+
+func (s *validatorStub) GetDartVersion() (*dart.Detection, error) {
+	return &dart.Detection{Version: v.MustParse(s.dartVersion), Method: "dart --version"}, nil
+}
+
+func (s *validatorStub) GetPatrolCLIVersion() (*get_cli_version.Detection, error) {
+	if s.installedCLI == "" {
+		return nil, errors.New("patrol: command not found")
+	}
+	return &get_cli_version.Detection{Version: v.MustParse(s.installedCLI), Method: "patrol --version"}, nil
+}
+
+func TestRunDartCheck(t *testing.T) {
+	tests := []struct {
+		name      string
+		stub      *validatorStub
+		requested string
+		hosted    bool
+		wantErr   string
+	}{
+		{
+			name:      "requested_version_too_new",
+			stub:      &validatorStub{dartVersion: "3.5.4"},
+			requested: "4.0.1",
+			hosted:    true,
+			wantErr:   "Dart SDK 3.5.4 is too old for Patrol CLI 4.0.1, which requires Dart 3.8.0 or newer",
+		},
+		{
+			name:    "latest_activated_by_default",
+			stub:    &validatorStub{dartVersion: "3.5.4"},
+			hosted:  true,
+			wantErr: "Dart SDK 3.5.4 is too old for Patrol CLI 4.0.1 (the latest release",
+		},
+		{
+			name:   "latest_supported",
+			stub:   &validatorStub{dartVersion: "3.8.1"},
+			hosted: true,
+		},
+		{
+			name:   "installed_cli_is_kept",
+			stub:   &validatorStub{dartVersion: "3.5.4", installedCLI: "3.6.0"},
+			hosted: true,
+		},
+		{
+			name: "git_source_version_unknown",
+			stub: &validatorStub{dartVersion: "3.5.4"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dartVersion, err := RunDartCheck(DartCheckRunParams{
+				Runner:              tt.stub,
+				RequestedCLIVersion: tt.requested,
+				HostedSource:        tt.hosted,
+			})
+
+			if dartVersion == nil || dartVersion.String() != tt.stub.dartVersion {
+				t.Errorf("expected Dart %s to be returned, got %v", tt.stub.dartVersion, dartVersion)
+			}
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("expected an error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
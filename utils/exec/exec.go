@@ -21,3 +21,15 @@ func Command(cmd commands.Command) (string, error) {
 
 	return out.String(), nil
 }
+
+// / Executes a command and returns its combined stdout and stderr, also when the command fails.
+func CombinedCommand(cmd commands.Command) (string, error) {
+	command := exec.Command(cmd.Name, cmd.Args...)
+	out, err := command.CombinedOutput()
+
+	if err != nil {
+		return string(out), fmt.Errorf("failed to run %s %s: %w", command, cmd.Args, err)
+	}
+
+	return string(out), nil
+}
@@ -0,0 +1,76 @@
+package pubspec
+
+import (
+	"fmt"
+	"os"
+
+	"gopkg.in/yaml.v3"
+)
+
+const LockFileName = "pubspec.lock"
+
+// Package sources as written by pub into pubspec.lock.
+const (
+	SourceHosted = "hosted"
+	SourceGit    = "git"
+	SourcePath   = "path"
+	SourceSDK    = "sdk"
+)
+
+// Lockfile is the subset of pubspec.lock needed to resolve package versions.
+type Lockfile struct {
+	Packages map[string]LockedPackage `yaml:"packages"`
+}
+
+// LockedPackage is a single resolved package entry.
+type LockedPackage struct {
+	Dependency  string          `yaml:"dependency"`
+	Description LockDescription `yaml:"description"`
+	Source      string          `yaml:"source"`
+	Version     string          `yaml:"version"`
+}
+
+// LockDescription holds the source specific details of a package.
+// SDK packages use a plain string ("flutter"), other sources a map.
+type LockDescription struct {
+	Name        string `yaml:"name"`
+	URL         string `yaml:"url"`
+	Path        string `yaml:"path"`
+	Ref         string `yaml:"ref"`
+	ResolvedRef string `yaml:"resolved-ref"`
+	Relative    bool   `yaml:"relative"`
+	SDK         string `yaml:"-"`
+}
+
+func (d *LockDescription) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		d.SDK = node.Value
+		return nil
+	}
+	type plain LockDescription
+	return node.Decode((*plain)(d))
+}
+
+// ReadLockfile reads and parses the pubspec.lock at path.
+func ReadLockfile(path string) (*Lockfile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	return ParseLockfile(data)
+}
+
+// ParseLockfile parses pubspec.lock content.
+func ParseLockfile(data []byte) (*Lockfile, error) {
+	lockfile := &Lockfile{}
+	if err := yaml.Unmarshal(data, lockfile); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", LockFileName, err)
+	}
+	return lockfile, nil
+}
+
+// Package returns the locked package with the given name.
+func (l *Lockfile) Package(name string) (LockedPackage, bool) {
+	pkg, ok := l.Packages[name]
+	return pkg, ok
+}
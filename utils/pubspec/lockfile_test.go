@@ -0,0 +1,111 @@
+package pubspec
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const sampleLockfile = `# Generated by pub
+# See https://dart.dev/tools/pub/glossary#lockfile
+packages:
+  flutter:
+    dependency: "direct main"
+    description: flutter
+    source: sdk
+    version: "0.0.0"
+  patrol:
+    dependency: "direct dev"
+    description:
+      name: patrol
+      sha256: "4f3ad7c1b0a1c2c4e5b0b6d8a8d9d2d1c2f6e0a1b2c3d4e5f6a7b8c9d0e1f2a3"
+      url: "https://pub.dev"
+    source: hosted
+    version: "3.15.1"
+  patrol_finders:
+    dependency: transitive
+    description:
+      path: "packages/patrol_finders"
+      ref: main
+      resolved-ref: "0123456789abcdef0123456789abcdef01234567"
+      url: "https://github.com/leancodepl/patrol.git"
+    source: git
+    version: "2.7.2"
+  patrol_log:
+    dependency: "direct overridden"
+    description:
+      path: "../patrol/packages/patrol_log"
+      relative: true
+    source: path
+    version: "0.3.0"
+sdks:
+  dart: ">=3.5.0 <4.0.0"
+  flutter: ">=3.24.0"
+`
+
+func TestParseLockfile(t *testing.T) {
+	lockfile, err := ParseLockfile([]byte(sampleLockfile))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		source     string
+		version    string
+		dependency string
+	}{
+		{name: "flutter", source: SourceSDK, version: "0.0.0", dependency: "direct main"},
+		{name: "patrol", source: SourceHosted, version: "3.15.1", dependency: "direct dev"},
+		{name: "patrol_finders", source: SourceGit, version: "2.7.2", dependency: "transitive"},
+		{name: "patrol_log", source: SourcePath, version: "0.3.0", dependency: "direct overridden"},
+	}
+
+	for _, tt := range tests {
+		pkg, ok := lockfile.Package(tt.name)
+		if !ok {
+			t.Fatalf("package %s not found", tt.name)
+		}
+		if pkg.Source != tt.source || pkg.Version != tt.version || pkg.Dependency != tt.dependency {
+			t.Errorf("%s = %+v, want source %s version %s dependency %s", tt.name, pkg, tt.source, tt.version, tt.dependency)
+		}
+	}
+
+	flutter, _ := lockfile.Package("flutter")
+	if flutter.Description.SDK != "flutter" {
+		t.Errorf("expected sdk description, got %+v", flutter.Description)
+	}
+	finders, _ := lockfile.Package("patrol_finders")
+	if finders.Description.URL != "https://github.com/leancodepl/patrol.git" || finders.Description.Ref != "main" {
+		t.Errorf("unexpected git description %+v", finders.Description)
+	}
+	log, _ := lockfile.Package("patrol_log")
+	if log.Description.Path != "../patrol/packages/patrol_log" || !log.Description.Relative {
+		t.Errorf("unexpected path description %+v", log.Description)
+	}
+}
+
+func TestParseLockfile_Invalid(t *testing.T) {
+	if _, err := ParseLockfile([]byte("packages: [")); err == nil {
+		t.Fatal("expected error for invalid YAML")
+	}
+}
+
+func TestReadLockfile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), LockFileName)
+	if err := os.WriteFile(path, []byte(sampleLockfile), 0644); err != nil {
+		t.Fatalf("failed to write lockfile: %v", err)
+	}
+
+	lockfile, err := ReadLockfile(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := lockfile.Package("patrol"); !ok {
+		t.Fatal("expected patrol package")
+	}
+
+	if _, err := ReadLockfile(filepath.Join(t.TempDir(), LockFileName)); err == nil {
+		t.Fatal("expected error for missing lockfile")
+	}
+}
@@ -0,0 +1,105 @@
+package pubspec
+
+import (
+	"fmt"
+	"os"
+
+	"gopkg.in/yaml.v3"
+)
+
+const FileName = "pubspec.yaml"
+
+// Pubspec is the subset of pubspec.yaml used by the step.
+type Pubspec struct {
+	Name    string `yaml:"name"`
+	Version string `yaml:"version"`
+	// Patrol is the `patrol:` section read by the Patrol CLI, nil when missing.
+	Patrol *PatrolConfig `yaml:"patrol"`
+	// Flutter is the `flutter:` section, nil when missing.
+	Flutter *FlutterConfig `yaml:"flutter"`
+}
+
+// FlutterConfig is the `flutter:` section of pubspec.yaml.
+type FlutterConfig struct {
+	Assets []FlutterAsset      `yaml:"assets"`
+	Fonts  []FlutterFontFamily `yaml:"fonts"`
+}
+
+// FlutterAsset is an entry of `assets:`, a file or a directory ending with a slash.
+// Since Flutter 3.22 an entry can also be a map with a path and e.g. flavors.
+type FlutterAsset struct {
+	Path string `yaml:"path"`
+}
+
+func (a *FlutterAsset) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		a.Path = node.Value
+		return nil
+	}
+	type plain FlutterAsset
+	return node.Decode((*plain)(a))
+}
+
+type FlutterFontFamily struct {
+	Family string        `yaml:"family"`
+	Fonts  []FlutterFont `yaml:"fonts"`
+}
+
+type FlutterFont struct {
+	Asset string `yaml:"asset"`
+}
+
+// AssetPaths returns the asset and font paths declared in the `flutter:` section.
+func (p *Pubspec) AssetPaths() []string {
+	if p.Flutter == nil {
+		return nil
+	}
+	var paths []string
+	for _, asset := range p.Flutter.Assets {
+		if asset.Path != "" {
+			paths = append(paths, asset.Path)
+		}
+	}
+	for _, family := range p.Flutter.Fonts {
+		for _, font := range family.Fonts {
+			if font.Asset != "" {
+				paths = append(paths, font.Asset)
+			}
+		}
+	}
+	return paths
+}
+
+// PatrolConfig is the `patrol:` section of pubspec.yaml.
+type PatrolConfig struct {
+	AppName       string              `yaml:"app_name"`
+	TestDirectory string              `yaml:"test_directory"`
+	Android       PatrolAndroidConfig `yaml:"android"`
+	IOS           PatrolIOSConfig     `yaml:"ios"`
+}
+
+type PatrolAndroidConfig struct {
+	PackageName string `yaml:"package_name"`
+}
+
+type PatrolIOSConfig struct {
+	BundleID string `yaml:"bundle_id"`
+}
+
+// ReadPubspec reads and parses the pubspec.yaml at path.
+func ReadPubspec(path string) (*Pubspec, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	return ParsePubspec(data)
+}
+
+// ParsePubspec parses pubspec.yaml content.
+func ParsePubspec(data []byte) (*Pubspec, error) {
+	pubspec := &Pubspec{}
+	if err := yaml.Unmarshal(data, pubspec); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", FileName, err)
+	}
+	return pubspec, nil
+}
@@ -0,0 +1,98 @@
+package pubspec
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestReadPubspec(t *testing.T) {
+	path := filepath.Join(t.TempDir(), FileName)
+	content := `name: patrol
+description: Powerful Flutter-native UI testing framework.
+version: 3.15.1+2
+`
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write pubspec: %v", err)
+	}
+
+	pubspec, err := ReadPubspec(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if pubspec.Name != "patrol" || pubspec.Version != "3.15.1+2" {
+		t.Errorf("unexpected pubspec %+v", pubspec)
+	}
+}
+
+func TestParsePubspec_Invalid(t *testing.T) {
+	if _, err := ParsePubspec([]byte("name: [")); err == nil {
+		t.Fatal("expected error for invalid YAML")
+	}
+}
+
+func TestParsePubspec_PatrolSection(t *testing.T) {
+	content := `name: example
+patrol:
+  app_name: Example
+  test_directory: integration_test
+  android:
+    package_name: com.example.app
+  ios:
+    bundle_id: com.example.App
+`
+	pubspec, err := ParsePubspec([]byte(content))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if pubspec.Patrol == nil {
+		t.Fatal("expected a patrol section")
+	}
+	patrol := pubspec.Patrol
+	if patrol.AppName != "Example" || patrol.TestDirectory != "integration_test" ||
+		patrol.Android.PackageName != "com.example.app" || patrol.IOS.BundleID != "com.example.App" {
+		t.Errorf("unexpected patrol section %+v", patrol)
+	}
+
+	pubspec, err = ParsePubspec([]byte("name: example\n"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if pubspec.Patrol != nil {
+		t.Errorf("expected no patrol section, got %+v", pubspec.Patrol)
+	}
+}
+
+func TestPubspec_AssetPaths(t *testing.T) {
+	content := `name: example
+flutter:
+  assets:
+    - assets/images/
+    - path: assets/config.json
+      flavors: [staging]
+  fonts:
+    - family: Inter
+      fonts:
+        - asset: fonts/Inter-Regular.ttf
+        - asset: fonts/Inter-Bold.ttf
+          weight: 700
+`
+	pubspec, err := ParsePubspec([]byte(content))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := []string{"assets/images/", "assets/config.json", "fonts/Inter-Regular.ttf", "fonts/Inter-Bold.ttf"}
+	if got := pubspec.AssetPaths(); !reflect.DeepEqual(got, want) {
+		t.Errorf("AssetPaths() = %q, want %q", got, want)
+	}
+
+	pubspec, err = ParsePubspec([]byte("name: example\n"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if paths := pubspec.AssetPaths(); paths != nil {
+		t.Errorf("expected no asset paths without a flutter section, got %q", paths)
+	}
+}
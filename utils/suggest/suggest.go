@@ -0,0 +1,69 @@
+package suggest
+
+import (
+	"sort"
+	"strings"
+)
+
+// Distance returns the Levenshtein edit distance between a and b.
+func Distance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	previous := make([]int, len(rb)+1)
+	current := make([]int, len(rb)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		current[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(rb)]
+}
+
+// Closest returns up to limit candidates close to target, nearest first.
+// A candidate is close when it is within a third of the target's length, and at least 2 edits.
+func Closest(target string, candidates []string, limit int) []string {
+	maxDistance := max(2, len([]rune(target))/3)
+
+	type scored struct {
+		value    string
+		distance int
+	}
+	var matches []scored
+	seen := map[string]bool{}
+	for _, candidate := range candidates {
+		if seen[candidate] {
+			continue
+		}
+		seen[candidate] = true
+
+		distance := Distance(strings.ToLower(target), strings.ToLower(candidate))
+		if distance <= maxDistance {
+			matches = append(matches, scored{value: candidate, distance: distance})
+		}
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		if matches[i].distance != matches[j].distance {
+			return matches[i].distance < matches[j].distance
+		}
+		return matches[i].value < matches[j].value
+	})
+
+	var closest []string
+	for _, match := range matches {
+		if len(closest) == limit {
+			break
+		}
+		closest = append(closest, match.value)
+	}
+	return closest
+}
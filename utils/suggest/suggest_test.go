@@ -0,0 +1,47 @@
+package suggest
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDistance(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"", "", 0},
+		{"abc", "", 3},
+		{"kitten", "sitting", 3},
+		{"patrol_test", "patrol_tests", 1},
+		{"smoke", "smoke", 0},
+	}
+	for _, tt := range tests {
+		if got := Distance(tt.a, tt.b); got != tt.want {
+			t.Errorf("Distance(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestClosest(t *testing.T) {
+	candidates := []string{
+		"patrol_test/login_test.dart",
+		"patrol_test/logout_test.dart",
+		"patrol_test/example_test.dart",
+		"patrol_test/login_test.dart",
+	}
+
+	got := Closest("patrol_test/logn_test.dart", candidates, 2)
+	want := []string{"patrol_test/login_test.dart", "patrol_test/logout_test.dart"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Closest() = %v, want %v", got, want)
+	}
+
+	if got := Closest("smok", []string{"smoke", "regression"}, 3); !reflect.DeepEqual(got, []string{"smoke"}) {
+		t.Errorf("Closest() = %v, want [smoke]", got)
+	}
+
+	if got := Closest("unrelated", candidates, 3); len(got) != 0 {
+		t.Errorf("Closest() = %v, want no suggestions", got)
+	}
+}